@@ -1,55 +1,74 @@
 package address
 
 import (
+	"fmt"
+
 	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/deployment"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
 )
 
+// TestnetJoyidCodeHash and MainnetJoyidCodeHash are the code hashes of the presets. MainnetJoyidCodeHash
+// was the testnet code hash before, and it is the mainnet one now.
+//
+// Deprecated: use deployment.Get(network).JoyIDLock.CodeHash instead
 const (
 	TestnetJoyidCodeHash = "0x07595bf3a836bdb7f93a91a66cb2c8cbaf0d807e8adecddc9d910aac6f0a5d0f"
-	MainnetJoyidCodeHash = "0x07595bf3a836bdb7f93a91a66cb2c8cbaf0d807e8adecddc9d910aac6f0a5d0f"
+	MainnetJoyidCodeHash = "0xd00c84f0ec8fd441c38bc3f87a371f547190f2fcff88e642bc5bf54b9e318323"
 )
 
 type JoyIDAddress struct {
 	joyidCodeHash string
 	network       types.Network
+	deployment    *deployment.Deployment
 }
 
+// NewJoyIDLock returns the JoyID address builder with the code hash, and the deployment of the network
+// is used if the code hash is empty
 func NewJoyIDLock(joyidCodeHash string, network types.Network) *JoyIDAddress {
 	return &JoyIDAddress{
-		joyidCodeHash,
-		network,
+		joyidCodeHash: joyidCodeHash,
+		network:       network,
+	}
+}
+
+// NewJoyIDLockWithDeployment returns the JoyID address builder of the deployment, e.g. the devnet
+func NewJoyIDLockWithDeployment(d *deployment.Deployment) *JoyIDAddress {
+	return &JoyIDAddress{
+		network:    d.Network,
+		deployment: d,
 	}
 }
 
+// DefaultJoyIDLock returns the JoyID address builder of the deployment used for the testnet
 func DefaultJoyIDLock() *JoyIDAddress {
 	return &JoyIDAddress{
-		joyidCodeHash: TestnetJoyidCodeHash,
-		network:       types.NetworkTest,
+		network: types.NetworkTest,
 	}
 }
 
-func (addr *JoyIDAddress) FromPubkeyHash(pubkeyHash []byte, algIndex alg.AlgIndex) *address.Address {
+// LockArgs returns the JoyID lock args which is made up of alg index and pubkey hash
+func LockArgs(pubkeyHash []byte, algIndex alg.AlgIndex) []byte {
 	var args []byte
 	if algIndex == alg.Secp256r1 {
 		args = []byte{0x00, 0x01}
 	} else {
 		args = []byte{0x00, 0x02}
 	}
-	args = append(args, pubkeyHash...)
-	var codeHash string
-	if addr.network == types.NetworkTest {
-		codeHash = TestnetJoyidCodeHash
-	} else {
-		codeHash = MainnetJoyidCodeHash
+	return append(args, pubkeyHash...)
+}
+
+func (addr *JoyIDAddress) FromPubkeyHash(pubkeyHash []byte, algIndex alg.AlgIndex) *address.Address {
+	d := addr.deployment
+	if d == nil {
+		d = deployment.Get(addr.network)
 	}
-	lockScript := &types.Script{
-		CodeHash: types.HexToHash(codeHash),
-		HashType: types.HashTypeType,
-		Args:     args,
+	lockScript := d.JoyIDLock.Script(LockArgs(pubkeyHash, algIndex))
+	if addr.joyidCodeHash != "" {
+		lockScript.CodeHash = types.HexToHash(addr.joyidCodeHash)
 	}
 	return &address.Address{
 		Script:  lockScript,
@@ -57,12 +76,49 @@ func (addr *JoyIDAddress) FromPubkeyHash(pubkeyHash []byte, algIndex alg.AlgInde
 	}
 }
 
-func (addr *JoyIDAddress) FromPrivKey(key string, algIndex alg.AlgIndex) *address.Address {
+// PublicKey is the secp256r1.PublicKey or the secp256k1.PublicKey
+type PublicKey interface {
+	PubkeyHash() []byte
+	Alg() alg.AlgIndex
+}
+
+// FromPublicKey returns the JoyID address of the public key, e.g. the COSE key of the passkey
+// parsed by secp256r1.ParsePublicKeyCOSE
+func (addr *JoyIDAddress) FromPublicKey(pubkey PublicKey) *address.Address {
+	return addr.FromPubkeyHash(pubkey.PubkeyHash(), pubkey.Alg())
+}
+
+// ImportPrivKey returns the JoyID address of the hex private key, or the error if the key is invalid
+func (addr *JoyIDAddress) ImportPrivKey(key string, algIndex alg.AlgIndex) (*address.Address, error) {
 	var pubkeyHash []byte
-	if algIndex == alg.Secp256k1 {
-		pubkeyHash = secp256k1.ImportKey(key).PubkeyHash()
-	} else {
-		pubkeyHash = secp256r1.ImportKey(key).PubkeyHash()
+	switch algIndex {
+	case alg.Secp256k1:
+		k, err := secp256k1.ImportKeyHex(key)
+		if err != nil {
+			return nil, err
+		}
+		pubkeyHash = k.PubkeyHash()
+	case alg.Secp256r1:
+		k, err := secp256r1.ImportKeyHex(key)
+		if err != nil {
+			return nil, err
+		}
+		pubkeyHash = k.PubkeyHash()
+	default:
+		return nil, fmt.Errorf("unknown alg index %d", algIndex)
+	}
+	return addr.FromPubkeyHash(pubkeyHash, algIndex), nil
+}
+
+// FromPrivKey returns the JoyID address of the hex private key, and it is nil if the key is invalid, use
+// ImportPrivKey to get the error of the key
+func (addr *JoyIDAddress) FromPrivKey(key string, algIndex alg.AlgIndex) *address.Address {
+	if algIndex != alg.Secp256k1 {
+		algIndex = alg.Secp256r1
+	}
+	result, err := addr.ImportPrivKey(key, algIndex)
+	if err != nil {
+		return nil
 	}
-	return addr.FromPubkeyHash(pubkeyHash, algIndex)
+	return result
 }
@@ -3,8 +3,13 @@ package address
 import (
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/deployment"
 	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
 )
 
 func TestFromR1PrivKey(t *testing.T) {
@@ -40,3 +45,62 @@ func TestFromK1PubkeyHash(t *testing.T) {
 		t.Errorf("FromPrivKey() = %q, want %q", got, want)
 	}
 }
+
+func TestFromDeployment(t *testing.T) {
+	pubkeyHash, _ := utils.HexToBytes("0x6500fc0e86fd49ef7dfc4b25dfd654eacaad53fb")
+	devnet := *deployment.Testnet
+	devnet.Name = "devnet"
+	devnet.JoyIDLock.CodeHash = types.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
+	tests := []struct {
+		name string
+		addr *JoyIDAddress
+		want types.Hash
+	}{
+		{"mainnet", NewJoyIDLock("", types.NetworkMain), deployment.Mainnet.JoyIDLock.CodeHash},
+		{"devnet", NewJoyIDLockWithDeployment(&devnet), devnet.JoyIDLock.CodeHash},
+		{"custom code hash", NewJoyIDLock(devnet.JoyIDLock.CodeHash.Hex(), types.NetworkTest), devnet.JoyIDLock.CodeHash},
+	}
+	for _, tt := range tests {
+		if got := tt.addr.FromPubkeyHash(pubkeyHash, alg.Secp256k1).Script.CodeHash; got != tt.want {
+			t.Errorf("%s: FromPubkeyHash() code hash = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImportPrivKey(t *testing.T) {
+	addr, err := DefaultJoyIDLock().ImportPrivKey("4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761", alg.Secp256r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ckt1qqr4jkln4qmtmdle82g6vm9jer967rvq069danwunkgs4tr0pfws7qgqq9sfrkfah2cj79nyp7e6p283ualq8779rsgww3jf"
+	if got, _ := addr.Encode(); got != want {
+		t.Errorf("ImportPrivKey() = %q, want %q", got, want)
+	}
+	if _, err := DefaultJoyIDLock().ImportPrivKey("0x00", alg.Secp256k1); err == nil {
+		t.Errorf("ImportPrivKey() should fail with zero key")
+	}
+	if got := DefaultJoyIDLock().FromPrivKey("zz-not-hex", alg.Secp256r1); got != nil {
+		t.Errorf("FromPrivKey() = %v, want nil with invalid key", got)
+	}
+}
+
+func TestFromPublicKey(t *testing.T) {
+	r1Pubkey := secp256r1.ImportKey("4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761").PublicKey()
+	cose, _ := cbor.Marshal(map[int]interface{}{1: 2, 3: -7, -1: 1, -2: r1Pubkey.Bytes()[:32], -3: r1Pubkey.Bytes()[32:]})
+	pubkey, err := secp256r1.ParsePublicKeyCOSE(cose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := DefaultJoyIDLock().FromPublicKey(pubkey).Encode()
+	want := "ckt1qqr4jkln4qmtmdle82g6vm9jer967rvq069danwunkgs4tr0pfws7qgqq9sfrkfah2cj79nyp7e6p283ualq8779rsgww3jf"
+	if got != want {
+		t.Errorf("FromPublicKey() = %q, want %q", got, want)
+	}
+
+	k1Pubkey, _ := secp256k1.ParsePublicKey(secp256k1.ImportKey("4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761").PublicKey().Compressed())
+	got, _ = DefaultJoyIDLock().FromPublicKey(k1Pubkey).Encode()
+	want = "ckt1qqr4jkln4qmtmdle82g6vm9jer967rvq069danwunkgs4tr0pfws7qgqqfjsplqwsm75nmmal39jth7k2n4v4t2nlvmef595"
+	if got != want {
+		t.Errorf("FromPublicKey() = %q, want %q", got, want)
+	}
+}
@@ -2,12 +2,16 @@ package aggregator
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
 	"github.com/nervina-labs/joyid-sdk-go/utils"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
@@ -16,158 +20,236 @@ import (
 type RPCClient struct {
 	url    string
 	client *http.Client
+	header http.Header
+	id     uint64
 }
 
+// Option configures the RPCClient
+type Option func(*RPCClient)
+
 type request struct {
-	Id      int                    `json:"id"`
-	JsonRpc string                 `json:"jsonrpc"`
-	Method  string                 `json:"method"`
-	Params  map[string]interface{} `json:"params"`
+	Id      uint64      `json:"id"`
+	JsonRpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
 }
 
-type rpcError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+type response struct {
+	Id     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RPCError       `json:"error,omitempty"`
 }
 
-type SubKeyUnlockResult struct {
-	UnlockEntry string `json:"unlock_entry"`
-	BlockNumber uint64 `json:"block_number"`
+// RPCError is the error object of the JSON-RPC response of the aggregator
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
-type SubKeyUnlockResp struct {
-	Result SubKeyUnlockResult `json:"result,omitempty"`
-	Error  rpcError           `json:"error,omitempty"`
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("aggregator request error %d, %s", e.Code, e.Message)
 }
 
-type ExtensionSubKeyResult struct {
-	ExtensionSmtEntry string `json:"extension_smt_entry"`
-	SmtRootHash       string `json:"smt_root_hash"`
-	BlockNumber       uint64 `json:"block_number"`
-}
+// ErrCodeMethodNotFound is the code of RPCError if the aggregator doesn't serve the method
+const ErrCodeMethodNotFound = -32601
 
-type ExtensionSubKeyResp struct {
-	Result ExtensionSubKeyResult `json:"result,omitempty"`
-	Error  rpcError              `json:"error,omitempty"`
+// ErrUnavailable is matched by errors.Is if the aggregator is not reachable or responds with
+// a non-2xx status
+var ErrUnavailable = errors.New("aggregator is unavailable")
+
+type unavailableError struct {
+	msg string
+	err error
 }
 
-func NewRPCClient(url string) *RPCClient {
-	client := &http.Client{
-		Timeout: time.Duration(1) * time.Minute,
-	}
-	return &RPCClient{
-		url,
-		client,
+func (e *unavailableError) Error() string {
+	if e.err == nil {
+		return e.msg
 	}
+	return e.msg + ", " + e.err.Error()
 }
 
-func (rpc *RPCClient) GetSubkeyUnlockSmt(address *address.Address, pubkeyHash []byte, algIndex alg.AlgIndex) (string, error) {
-	params := make(map[string]interface{})
-	params["lock_script"] = utils.BytesTo0xHex(address.Script.Serialize())
-	params["pubkey_hash"] = utils.BytesTo0xHex(pubkeyHash)
-	params["alg_index"] = algIndex
-
-	req := request{
-		Id:      1,
-		JsonRpc: "2.0",
-		Method:  "generate_subkey_unlock_smt",
-		Params:  params,
-	}
+func (e *unavailableError) Unwrap() error {
+	return e.err
+}
 
-	jsonReq, err := json.Marshal(req)
-	if err != nil {
-		return "", err
-	}
+func (e *unavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
 
-	httpReq, err := http.NewRequest(http.MethodPost, rpc.url, bytes.NewBuffer(jsonReq))
-	if err != nil {
-		return "", err
-	}
+type SubKeyUnlockResult struct {
+	UnlockEntry string `json:"unlock_entry"`
+	BlockNumber uint64 `json:"block_number"`
+}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+type ExtensionSubKeyResult struct {
+	ExtensionSmtEntry string `json:"extension_smt_entry"`
+	SmtRootHash       string `json:"smt_root_hash"`
+	BlockNumber       uint64 `json:"block_number"`
+}
 
-	resp, err := rpc.client.Do(httpReq)
-	if err != nil {
-		return "", fmt.Errorf("aggregator node is not reachable, %+v", err)
-	}
+// the ext_action of the extension subkey SMT
+const (
+	ExtActionAddSubkey    byte = 0xF0
+	ExtActionUpdateSubkey byte = 0xF1
+)
 
-	defer resp.Body.Close()
+// ExtensionSubkey is the subkey in the ext_data slot of the JoyID account
+type ExtensionSubkey struct {
+	ExtData    uint32        `json:"ext_data"`
+	AlgIndex   alg.AlgIndex  `json:"alg_index"`
+	PubkeyHash hexutil.Bytes `json:"pubkey_hash"`
+}
 
-	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-		responseBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
+type ExtensionSubkeyReq struct {
+	LockScript hexutil.Bytes     `json:"lock_script"`
+	ExtAction  HexByte           `json:"ext_action"`
+	Subkeys    []ExtensionSubkey `json:"subkeys"`
+}
 
-		var resp SubKeyUnlockResp
-		if err = json.Unmarshal(responseBody, &resp); err != nil { // Parse []byte to the go struct pointer
-			return "", err
+// WithHTTPClient replaces the default http.Client whose timeout is 1 minute, and the nil client keeps
+// the default one
+func WithHTTPClient(client *http.Client) Option {
+	return func(rpc *RPCClient) {
+		if client != nil {
+			rpc.client = client
 		}
+	}
+}
 
-		if resp.Error.Code != 0 {
-			return "", fmt.Errorf("aggregator request error, %v", resp.Error.Message)
+// WithTransport sets the RoundTripper of the http.Client
+func WithTransport(transport http.RoundTripper) Option {
+	return func(rpc *RPCClient) {
+		if rpc.client == nil {
+			rpc.client = defaultHTTPClient()
 		}
-
-		return resp.Result.UnlockEntry, nil
+		client := *rpc.client
+		client.Transport = transport
+		rpc.client = &client
 	}
+}
 
-	return "", fmt.Errorf("invalid aggregator request, %v", err)
+// WithHeader adds the header to every request, e.g. the authorization of the aggregator deployment
+func WithHeader(key, value string) Option {
+	return func(rpc *RPCClient) {
+		rpc.header.Add(key, value)
+	}
 }
 
-func (rpc *RPCClient) GetExtensionSubkeySmt(address *address.Address, pubkeyHash []byte, algIndex alg.AlgIndex, extData uint32) (*ExtensionSubKeyResult, error) {
-	subkey := make(map[string]interface{})
-	subkey["ext_data"] = extData
-	subkey["alg_index"] = algIndex
-	subkey["pubkey_hash"] = utils.BytesTo0xHex(pubkeyHash)
+func defaultHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Duration(1) * time.Minute,
+	}
+}
 
-	params := make(map[string]interface{})
-	params["lock_script"] = utils.BytesTo0xHex(address.Script.Serialize())
-	params["ext_action"] = "0xF0"
-	params["subkeys"] = []map[string]interface{}{subkey}
+func NewRPCClient(url string, opts ...Option) *RPCClient {
+	rpc := &RPCClient{
+		url:    url,
+		client: defaultHTTPClient(),
+		header: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(rpc)
+	}
+	return rpc
+}
 
+// Call sends the JSON-RPC request to the aggregator and unmarshals the result into result,
+// and a *RPCError is returned if the response has an error object
+func (rpc *RPCClient) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
 	req := request{
-		Id:      1,
+		Id:      atomic.AddUint64(&rpc.id, 1),
 		JsonRpc: "2.0",
-		Method:  "generate_extension_subkey_smt",
+		Method:  method,
 		Params:  params,
 	}
-
 	jsonReq, err := json.Marshal(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	httpReq, err := http.NewRequest(http.MethodPost, rpc.url, bytes.NewBuffer(jsonReq))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, rpc.url, bytes.NewBuffer(jsonReq))
 	if err != nil {
-		return nil, err
+		return err
+	}
+	for key, values := range rpc.header {
+		httpReq.Header[key] = values
 	}
-
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := rpc.client.Do(httpReq)
+	httpResp, err := rpc.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("aggregator node is not reachable, %+v", err)
+		return &unavailableError{msg: "aggregator node is not reachable", err: err}
 	}
+	defer httpResp.Body.Close()
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-		responseBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
+	responseBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	var resp response
+	err = json.Unmarshal(responseBody, &resp)
+	// the JSON-RPC error may come with any status, and the other non-2xx responses are from the proxy
+	// or the node which cannot serve the request
+	if (err != nil || resp.Error == nil) && (httpResp.StatusCode < 200 || httpResp.StatusCode > 299) {
+		return &unavailableError{msg: "invalid aggregator request, status " + httpResp.Status}
+	}
+	if err != nil {
+		return err
+	}
+	// the error response of the request which cannot be parsed has null id
+	if resp.Id != req.Id && (resp.Error == nil || resp.Id != 0) {
+		return fmt.Errorf("aggregator response id %d doesn't match request id %d", resp.Id, req.Id)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
 
-		var resp ExtensionSubKeyResp
-		if err = json.Unmarshal(responseBody, &resp); err != nil { // Parse []byte to the go struct pointer
-			return nil, err
-		}
+func (rpc *RPCClient) GetSubkeyUnlockSmt(address *address.Address, pubkeyHash []byte, algIndex alg.AlgIndex) (string, error) {
+	return rpc.GetSubkeyUnlockSmtContext(context.Background(), address, pubkeyHash, algIndex)
+}
 
-		if resp.Error.Code != 0 {
-			return nil, fmt.Errorf("aggregator request error, %v", resp.Error.Message)
-		}
+func (rpc *RPCClient) GetSubkeyUnlockSmtContext(ctx context.Context, address *address.Address, pubkeyHash []byte, algIndex alg.AlgIndex) (string, error) {
+	params := make(map[string]interface{})
+	params["lock_script"] = utils.BytesTo0xHex(address.Script.Serialize())
+	params["pubkey_hash"] = utils.BytesTo0xHex(pubkeyHash)
+	params["alg_index"] = algIndex
 
-		return &resp.Result, nil
+	var result SubKeyUnlockResult
+	if err := rpc.Call(ctx, "generate_subkey_unlock_smt", params, &result); err != nil {
+		return "", err
 	}
+	return result.UnlockEntry, nil
+}
+
+func (rpc *RPCClient) GetExtensionSubkeySmt(address *address.Address, pubkeyHash []byte, algIndex alg.AlgIndex, extData uint32) (*ExtensionSubKeyResult, error) {
+	return rpc.GetExtensionSubkeySmtContext(context.Background(), address, pubkeyHash, algIndex, extData)
+}
 
-	return nil, fmt.Errorf("invalid aggregator request, %v", err)
+func (rpc *RPCClient) GetExtensionSubkeySmtContext(ctx context.Context, address *address.Address, pubkeyHash []byte, algIndex alg.AlgIndex, extData uint32) (*ExtensionSubKeyResult, error) {
+	return rpc.GenerateExtensionSubkeySmtContext(ctx, &ExtensionSubkeyReq{
+		LockScript: LockScript(address.Script),
+		ExtAction:  HexByte(ExtActionAddSubkey),
+		Subkeys:    []ExtensionSubkey{{ExtData: extData, AlgIndex: algIndex, PubkeyHash: pubkeyHash}},
+	})
+}
+
+// GenerateExtensionSubkeySmt generates the extension SMT entry which adds or updates the subkeys
+// with the ext action
+func (rpc *RPCClient) GenerateExtensionSubkeySmt(req *ExtensionSubkeyReq) (*ExtensionSubKeyResult, error) {
+	return rpc.GenerateExtensionSubkeySmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateExtensionSubkeySmtContext(ctx context.Context, req *ExtensionSubkeyReq) (*ExtensionSubKeyResult, error) {
+	var result ExtensionSubKeyResult
+	if err := rpc.Call(ctx, "generate_extension_subkey_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
@@ -0,0 +1,147 @@
+package aggregator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+)
+
+func TestCall(t *testing.T) {
+	var ids []uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %s, want Bearer token", got)
+		}
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		ids = append(ids, req.Id)
+		switch req.Method {
+		case "generate_subkey_unlock_smt":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"unlock_entry":"0x01","block_number":10}}`, req.Id)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"Method not found"}}`, req.Id)
+		}
+	}))
+	defer server.Close()
+
+	rpc := NewRPCClient(server.URL, WithHeader("Authorization", "Bearer token"))
+	entry, err := rpc.GetSubkeyUnlockSmt(testutil.Address(), make([]byte, 20), alg.Secp256r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != "0x01" {
+		t.Errorf("GetSubkeyUnlockSmt() = %s, want 0x01", entry)
+	}
+
+	_, err = rpc.GetExtensionSubkeySmt(testutil.Address(), make([]byte, 20), alg.Secp256r1, 1)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Errorf("GetExtensionSubkeySmt() error = %v, want *RPCError with code -32601", err)
+	}
+	if len(ids) != 2 || ids[0] >= ids[1] {
+		t.Errorf("request ids = %v, want increasing ids", ids)
+	}
+}
+
+func TestCallWithContext(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	rpc := NewRPCClient(server.URL, WithHTTPClient(&http.Client{}))
+	if _, err := rpc.GetSubkeyUnlockSmtContext(ctx, testutil.Address(), make([]byte, 20), alg.Secp256r1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetSubkeyUnlockSmtContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+type countingTransport struct {
+	count int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.count++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	if err := NewRPCClient(server.URL, WithTransport(transport)).Call(context.Background(), "ping", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if transport.count != 1 {
+		t.Errorf("RoundTrip() count = %d, want 1", transport.count)
+	}
+}
+
+func TestWithNilHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	rpc := NewRPCClient(server.URL, WithHTTPClient(nil), WithTransport(transport))
+	if err := rpc.Call(context.Background(), "ping", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if transport.count != 1 {
+		t.Errorf("RoundTrip() count = %d, want 1", transport.count)
+	}
+}
+
+func TestCallWithMismatchedId(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":100,"result":null}`))
+	}))
+	defer server.Close()
+
+	if err := NewRPCClient(server.URL).Call(context.Background(), "ping", nil, nil); err == nil {
+		t.Errorf("Call() should fail with mismatched response id")
+	}
+}
+
+func TestCallWithUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	if err := NewRPCClient(server.URL).Call(context.Background(), "ping", nil, nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Call() error = %v, want %v", err, ErrUnavailable)
+	}
+	server.Close()
+	if err := NewRPCClient(server.URL).Call(context.Background(), "ping", nil, nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Call() error = %v, want %v", err, ErrUnavailable)
+	}
+}
+
+func TestCallWithUnavailableJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"message":"upstream is down"}`))
+	}))
+	defer server.Close()
+	if err := NewRPCClient(server.URL).Call(context.Background(), "ping", nil, nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Call() error = %v, want %v", err, ErrUnavailable)
+	}
+}
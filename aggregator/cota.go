@@ -0,0 +1,531 @@
+package aggregator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// HexUint32 is encoded as the 4-byte big-endian hex by the aggregator, e.g. token_index, total and issued
+type HexUint32 uint32
+
+// HexByte is encoded as the 1-byte hex by the aggregator, e.g. state and configure
+type HexByte byte
+
+func (n HexUint32) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("0x%08x", uint32(n)))
+}
+
+// UnmarshalJSON accepts both the hex string and the JSON number
+func (n *HexUint32) UnmarshalJSON(input []byte) error {
+	value, err := unmarshalHexUint(input, 32)
+	*n = HexUint32(value)
+	return err
+}
+
+func (b HexByte) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("0x%02x", byte(b)))
+}
+
+func (b *HexByte) UnmarshalJSON(input []byte) error {
+	value, err := unmarshalHexUint(input, 8)
+	*b = HexByte(value)
+	return err
+}
+
+func unmarshalHexUint(input []byte, bitSize int) (uint64, error) {
+	var str string
+	if err := json.Unmarshal(input, &str); err != nil {
+		return strconv.ParseUint(string(input), 10, bitSize)
+	}
+	return strconv.ParseUint(strings.TrimPrefix(str, "0x"), 16, bitSize)
+}
+
+// LockScript returns the hex of the serialized lock script which is the lock_script param of the aggregator
+func LockScript(script *types.Script) hexutil.Bytes {
+	return script.Serialize()
+}
+
+type DefineCotaReq struct {
+	LockScript hexutil.Bytes `json:"lock_script"`
+	CotaId     hexutil.Bytes `json:"cota_id"`
+	Total      HexUint32     `json:"total"`
+	Issued     HexUint32     `json:"issued"`
+	Configure  HexByte       `json:"configure"`
+}
+
+type DefineCotaResult struct {
+	SmtRootHash    hexutil.Bytes `json:"smt_root_hash"`
+	DefineSmtEntry hexutil.Bytes `json:"define_smt_entry"`
+	BlockNumber    uint64        `json:"block_number"`
+}
+
+type MintWithdrawal struct {
+	TokenIndex     HexUint32     `json:"token_index"`
+	State          HexByte       `json:"state"`
+	Characteristic hexutil.Bytes `json:"characteristic"`
+	ToLockScript   hexutil.Bytes `json:"to_lock_script"`
+}
+
+type MintCotaReq struct {
+	LockScript hexutil.Bytes `json:"lock_script"`
+	CotaId     hexutil.Bytes `json:"cota_id"`
+	// OutPoint is the last 24 bytes of the serialized out point of the CoTA cell
+	OutPoint    hexutil.Bytes    `json:"out_point"`
+	Withdrawals []MintWithdrawal `json:"withdrawals"`
+}
+
+type MintCotaResult struct {
+	SmtRootHash  hexutil.Bytes `json:"smt_root_hash"`
+	MintSmtEntry hexutil.Bytes `json:"mint_smt_entry"`
+	BlockNumber  uint64        `json:"block_number"`
+}
+
+type WithdrawalNft struct {
+	CotaId       hexutil.Bytes `json:"cota_id"`
+	TokenIndex   HexUint32     `json:"token_index"`
+	ToLockScript hexutil.Bytes `json:"to_lock_script"`
+}
+
+type WithdrawalCotaReq struct {
+	LockScript  hexutil.Bytes   `json:"lock_script"`
+	OutPoint    hexutil.Bytes   `json:"out_point"`
+	Withdrawals []WithdrawalNft `json:"withdrawals"`
+}
+
+type WithdrawalCotaResult struct {
+	SmtRootHash        hexutil.Bytes `json:"smt_root_hash"`
+	WithdrawalSmtEntry hexutil.Bytes `json:"withdrawal_smt_entry"`
+	BlockNumber        uint64        `json:"block_number"`
+}
+
+type ClaimNft struct {
+	CotaId     hexutil.Bytes `json:"cota_id"`
+	TokenIndex HexUint32     `json:"token_index"`
+}
+
+type ClaimCotaReq struct {
+	LockScript         hexutil.Bytes `json:"lock_script"`
+	WithdrawalLockHash hexutil.Bytes `json:"withdrawal_lock_hash"`
+	Claims             []ClaimNft    `json:"claims"`
+}
+
+type ClaimCotaResult struct {
+	SmtRootHash   hexutil.Bytes `json:"smt_root_hash"`
+	ClaimSmtEntry hexutil.Bytes `json:"claim_smt_entry"`
+	BlockNumber   uint64        `json:"block_number"`
+}
+
+type TransferNft struct {
+	CotaId       hexutil.Bytes `json:"cota_id"`
+	TokenIndex   HexUint32     `json:"token_index"`
+	ToLockScript hexutil.Bytes `json:"to_lock_script"`
+}
+
+type TransferCotaReq struct {
+	LockScript         hexutil.Bytes `json:"lock_script"`
+	WithdrawalLockHash hexutil.Bytes `json:"withdrawal_lock_hash"`
+	TransferOutPoint   hexutil.Bytes `json:"transfer_out_point"`
+	Transfers          []TransferNft `json:"transfers"`
+}
+
+type TransferCotaResult struct {
+	SmtRootHash      hexutil.Bytes `json:"smt_root_hash"`
+	TransferSmtEntry hexutil.Bytes `json:"transfer_smt_entry"`
+	BlockNumber      uint64        `json:"block_number"`
+}
+
+type UpdateNft struct {
+	CotaId         hexutil.Bytes `json:"cota_id"`
+	TokenIndex     HexUint32     `json:"token_index"`
+	State          HexByte       `json:"state"`
+	Characteristic hexutil.Bytes `json:"characteristic"`
+}
+
+type UpdateCotaReq struct {
+	LockScript hexutil.Bytes `json:"lock_script"`
+	Nfts       []UpdateNft   `json:"nfts"`
+}
+
+type UpdateCotaResult struct {
+	SmtRootHash    hexutil.Bytes `json:"smt_root_hash"`
+	UpdateSmtEntry hexutil.Bytes `json:"update_smt_entry"`
+	BlockNumber    uint64        `json:"block_number"`
+}
+
+// CotaNft is the NFT with the metadata of its class
+type CotaNft struct {
+	CotaId             hexutil.Bytes `json:"cota_id"`
+	TokenIndex         HexUint32     `json:"token_index"`
+	State              HexByte       `json:"state"`
+	Configure          HexByte       `json:"configure"`
+	Characteristic     hexutil.Bytes `json:"characteristic"`
+	Name               string        `json:"name"`
+	Description        string        `json:"description"`
+	Image              string        `json:"image"`
+	Audio              string        `json:"audio"`
+	Video              string        `json:"video"`
+	Model              string        `json:"model"`
+	MetaCharacteristic string        `json:"meta_characteristic"`
+	Properties         string        `json:"properties"`
+}
+
+type HoldCotaNftResult struct {
+	Total       uint64    `json:"total"`
+	Nfts        []CotaNft `json:"nfts"`
+	PageSize    uint64    `json:"page_size"`
+	BlockNumber uint64    `json:"block_number"`
+}
+
+type WithdrawalCotaNftResult struct {
+	Total       uint64    `json:"total"`
+	Nfts        []CotaNft `json:"nfts"`
+	PageSize    uint64    `json:"page_size"`
+	BlockNumber uint64    `json:"block_number"`
+}
+
+// MintCotaNft is the NFT minted by the issuer with the receiver lock script
+type MintCotaNft struct {
+	CotaNft
+	ReceiverLock hexutil.Bytes `json:"receiver_lock"`
+}
+
+type MintCotaNftResult struct {
+	Total       uint64        `json:"total"`
+	Nfts        []MintCotaNft `json:"nfts"`
+	PageSize    uint64        `json:"page_size"`
+	BlockNumber uint64        `json:"block_number"`
+}
+
+type IsClaimedResult struct {
+	Claimed     bool   `json:"claimed"`
+	BlockNumber uint64 `json:"block_number"`
+}
+
+type CotaNftSenderResult struct {
+	SenderLockHash hexutil.Bytes `json:"sender_lock_hash"`
+	BlockNumber    uint64        `json:"block_number"`
+}
+
+type DefineInfoResult struct {
+	Total              HexUint32 `json:"total"`
+	Issued             HexUint32 `json:"issued"`
+	Configure          HexByte   `json:"configure"`
+	Name               string    `json:"name"`
+	Description        string    `json:"description"`
+	Image              string    `json:"image"`
+	Audio              string    `json:"audio"`
+	Video              string    `json:"video"`
+	Model              string    `json:"model"`
+	MetaCharacteristic string    `json:"meta_characteristic"`
+	Properties         string    `json:"properties"`
+	Localization       string    `json:"localization"`
+	BlockNumber        uint64    `json:"block_number"`
+}
+
+type IssuerInfoResult struct {
+	Name         string `json:"name"`
+	Avatar       string `json:"avatar"`
+	Description  string `json:"description"`
+	Localization string `json:"localization"`
+	BlockNumber  uint64 `json:"block_number"`
+}
+
+type CotaCountResult struct {
+	Count       uint64 `json:"count"`
+	BlockNumber uint64 `json:"block_number"`
+}
+
+type HistoryTransaction struct {
+	BlockNumber uint64        `json:"block_number"`
+	From        hexutil.Bytes `json:"from"`
+	To          hexutil.Bytes `json:"to"`
+	TxHash      hexutil.Bytes `json:"tx_hash"`
+	TxType      string        `json:"tx_type"`
+}
+
+type HistoryTransactionsResult struct {
+	Total        uint64               `json:"total"`
+	Transactions []HistoryTransaction `json:"transactions"`
+	PageSize     uint64               `json:"page_size"`
+	BlockNumber  uint64               `json:"block_number"`
+}
+
+// JoyIDSubkey is the subkey in the JoyID info
+type JoyIDSubkey struct {
+	PubKey       hexutil.Bytes `json:"pub_key"`
+	CredentialId hexutil.Bytes `json:"credential_id"`
+	Alg          string        `json:"alg"`
+	FrontEnd     string        `json:"front_end"`
+}
+
+type JoyIDInfoResult struct {
+	Name         string        `json:"name"`
+	Avatar       string        `json:"avatar"`
+	Description  string        `json:"description"`
+	Extension    string        `json:"extension"`
+	Nickname     string        `json:"nickname"`
+	PubKey       hexutil.Bytes `json:"pub_key"`
+	CredentialId hexutil.Bytes `json:"credential_id"`
+	Alg          string        `json:"alg"`
+	CotaCellId   string        `json:"cota_cell_id"`
+	FrontEnd     string        `json:"front_end"`
+	Subkeys      []JoyIDSubkey `json:"sub_keys"`
+	BlockNumber  uint64        `json:"block_number"`
+}
+
+// RegisterCotaResult is the result of the CoTA registry aggregator
+type RegisterCotaResult struct {
+	SmtRootHash      hexutil.Bytes `json:"smt_root_hash"`
+	RegistrySmtEntry hexutil.Bytes `json:"registry_smt_entry"`
+	OutputAccountNum uint64        `json:"output_account_num"`
+	BlockNumber      uint64        `json:"block_number"`
+}
+
+// RegisterCotaCells generates the registry SMT entry of the lock hashes, and it is served by the
+// CoTA registry aggregator instead of the CoTA aggregator
+func (rpc *RPCClient) RegisterCotaCells(lockHashes []types.Hash) (*RegisterCotaResult, error) {
+	return rpc.RegisterCotaCellsContext(context.Background(), lockHashes)
+}
+
+func (rpc *RPCClient) RegisterCotaCellsContext(ctx context.Context, lockHashes []types.Hash) (*RegisterCotaResult, error) {
+	var result RegisterCotaResult
+	if err := rpc.Call(ctx, "register_cota_cells", lockHashes, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GenerateDefineCotaSmt(req *DefineCotaReq) (*DefineCotaResult, error) {
+	return rpc.GenerateDefineCotaSmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateDefineCotaSmtContext(ctx context.Context, req *DefineCotaReq) (*DefineCotaResult, error) {
+	var result DefineCotaResult
+	if err := rpc.Call(ctx, "generate_define_cota_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GenerateMintCotaSmt(req *MintCotaReq) (*MintCotaResult, error) {
+	return rpc.GenerateMintCotaSmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateMintCotaSmtContext(ctx context.Context, req *MintCotaReq) (*MintCotaResult, error) {
+	var result MintCotaResult
+	if err := rpc.Call(ctx, "generate_mint_cota_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GenerateWithdrawalCotaSmt(req *WithdrawalCotaReq) (*WithdrawalCotaResult, error) {
+	return rpc.GenerateWithdrawalCotaSmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateWithdrawalCotaSmtContext(ctx context.Context, req *WithdrawalCotaReq) (*WithdrawalCotaResult, error) {
+	var result WithdrawalCotaResult
+	if err := rpc.Call(ctx, "generate_withdrawal_cota_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GenerateClaimCotaSmt(req *ClaimCotaReq) (*ClaimCotaResult, error) {
+	return rpc.GenerateClaimCotaSmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateClaimCotaSmtContext(ctx context.Context, req *ClaimCotaReq) (*ClaimCotaResult, error) {
+	var result ClaimCotaResult
+	if err := rpc.Call(ctx, "generate_claim_cota_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GenerateTransferCotaSmt(req *TransferCotaReq) (*TransferCotaResult, error) {
+	return rpc.GenerateTransferCotaSmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateTransferCotaSmtContext(ctx context.Context, req *TransferCotaReq) (*TransferCotaResult, error) {
+	var result TransferCotaResult
+	if err := rpc.Call(ctx, "generate_transfer_cota_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GenerateUpdateCotaSmt(req *UpdateCotaReq) (*UpdateCotaResult, error) {
+	return rpc.GenerateUpdateCotaSmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateUpdateCotaSmtContext(ctx context.Context, req *UpdateCotaReq) (*UpdateCotaResult, error) {
+	var result UpdateCotaResult
+	if err := rpc.Call(ctx, "generate_update_cota_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetHoldCotaNft returns the NFTs held by the lock script, and all classes are returned if cotaId is empty
+func (rpc *RPCClient) GetHoldCotaNft(lockScript *types.Script, cotaId []byte, page, pageSize uint64) (*HoldCotaNftResult, error) {
+	return rpc.GetHoldCotaNftContext(context.Background(), lockScript, cotaId, page, pageSize)
+}
+
+func (rpc *RPCClient) GetHoldCotaNftContext(ctx context.Context, lockScript *types.Script, cotaId []byte, page, pageSize uint64) (*HoldCotaNftResult, error) {
+	var result HoldCotaNftResult
+	if err := rpc.Call(ctx, "get_hold_cota_nft", pageParams(lockScript, cotaId, page, pageSize), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetWithdrawalCotaNft returns the NFTs withdrawn by the lock script, and all classes are returned if cotaId is empty
+func (rpc *RPCClient) GetWithdrawalCotaNft(lockScript *types.Script, cotaId []byte, page, pageSize uint64) (*WithdrawalCotaNftResult, error) {
+	return rpc.GetWithdrawalCotaNftContext(context.Background(), lockScript, cotaId, page, pageSize)
+}
+
+func (rpc *RPCClient) GetWithdrawalCotaNftContext(ctx context.Context, lockScript *types.Script, cotaId []byte, page, pageSize uint64) (*WithdrawalCotaNftResult, error) {
+	var result WithdrawalCotaNftResult
+	if err := rpc.Call(ctx, "get_withdrawal_cota_nft", pageParams(lockScript, cotaId, page, pageSize), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetMintCotaNft returns the NFTs minted by the issuer lock script
+func (rpc *RPCClient) GetMintCotaNft(lockScript *types.Script, page, pageSize uint64) (*MintCotaNftResult, error) {
+	return rpc.GetMintCotaNftContext(context.Background(), lockScript, page, pageSize)
+}
+
+func (rpc *RPCClient) GetMintCotaNftContext(ctx context.Context, lockScript *types.Script, page, pageSize uint64) (*MintCotaNftResult, error) {
+	var result MintCotaNftResult
+	if err := rpc.Call(ctx, "get_mint_cota_nft", pageParams(lockScript, nil, page, pageSize), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) IsClaimed(lockScript *types.Script, cotaId []byte, tokenIndex uint32) (*IsClaimedResult, error) {
+	return rpc.IsClaimedContext(context.Background(), lockScript, cotaId, tokenIndex)
+}
+
+func (rpc *RPCClient) IsClaimedContext(ctx context.Context, lockScript *types.Script, cotaId []byte, tokenIndex uint32) (*IsClaimedResult, error) {
+	var result IsClaimedResult
+	if err := rpc.Call(ctx, "is_claimed", nftParams(lockScript, cotaId, tokenIndex), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GetCotaNftSender(lockScript *types.Script, cotaId []byte, tokenIndex uint32) (*CotaNftSenderResult, error) {
+	return rpc.GetCotaNftSenderContext(context.Background(), lockScript, cotaId, tokenIndex)
+}
+
+func (rpc *RPCClient) GetCotaNftSenderContext(ctx context.Context, lockScript *types.Script, cotaId []byte, tokenIndex uint32) (*CotaNftSenderResult, error) {
+	var result CotaNftSenderResult
+	if err := rpc.Call(ctx, "get_cota_nft_sender", nftParams(lockScript, cotaId, tokenIndex), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GetDefineInfo(cotaId []byte) (*DefineInfoResult, error) {
+	return rpc.GetDefineInfoContext(context.Background(), cotaId)
+}
+
+func (rpc *RPCClient) GetDefineInfoContext(ctx context.Context, cotaId []byte) (*DefineInfoResult, error) {
+	params := map[string]interface{}{"cota_id": hexutil.Bytes(cotaId)}
+	var result DefineInfoResult
+	if err := rpc.Call(ctx, "get_define_info", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GetIssuerInfo(lockScript *types.Script) (*IssuerInfoResult, error) {
+	return rpc.GetIssuerInfoContext(context.Background(), lockScript)
+}
+
+func (rpc *RPCClient) GetIssuerInfoContext(ctx context.Context, lockScript *types.Script) (*IssuerInfoResult, error) {
+	params := map[string]interface{}{"lock_script": LockScript(lockScript)}
+	var result IssuerInfoResult
+	if err := rpc.Call(ctx, "get_issuer_info", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetCotaCount returns the count of the NFTs of the class held by the lock script
+func (rpc *RPCClient) GetCotaCount(lockScript *types.Script, cotaId []byte) (*CotaCountResult, error) {
+	return rpc.GetCotaCountContext(context.Background(), lockScript, cotaId)
+}
+
+func (rpc *RPCClient) GetCotaCountContext(ctx context.Context, lockScript *types.Script, cotaId []byte) (*CotaCountResult, error) {
+	params := map[string]interface{}{
+		"lock_script": LockScript(lockScript),
+		"cota_id":     hexutil.Bytes(cotaId),
+	}
+	var result CotaCountResult
+	if err := rpc.Call(ctx, "get_cota_count", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GetHistoryTransactions(cotaId []byte, tokenIndex uint32, page, pageSize uint64) (*HistoryTransactionsResult, error) {
+	return rpc.GetHistoryTransactionsContext(context.Background(), cotaId, tokenIndex, page, pageSize)
+}
+
+func (rpc *RPCClient) GetHistoryTransactionsContext(ctx context.Context, cotaId []byte, tokenIndex uint32, page, pageSize uint64) (*HistoryTransactionsResult, error) {
+	params := map[string]interface{}{
+		"cota_id":     hexutil.Bytes(cotaId),
+		"token_index": HexUint32(tokenIndex),
+		"page":        page,
+		"page_size":   pageSize,
+	}
+	var result HistoryTransactionsResult
+	if err := rpc.Call(ctx, "get_history_transactions", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (rpc *RPCClient) GetJoyIDInfo(lockScript *types.Script) (*JoyIDInfoResult, error) {
+	return rpc.GetJoyIDInfoContext(context.Background(), lockScript)
+}
+
+func (rpc *RPCClient) GetJoyIDInfoContext(ctx context.Context, lockScript *types.Script) (*JoyIDInfoResult, error) {
+	params := map[string]interface{}{"lock_script": LockScript(lockScript)}
+	var result JoyIDInfoResult
+	if err := rpc.Call(ctx, "get_joyid_info", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func pageParams(lockScript *types.Script, cotaId []byte, page, pageSize uint64) map[string]interface{} {
+	params := map[string]interface{}{
+		"lock_script": LockScript(lockScript),
+		"page":        page,
+		"page_size":   pageSize,
+	}
+	if len(cotaId) > 0 {
+		params["cota_id"] = hexutil.Bytes(cotaId)
+	}
+	return params
+}
+
+func nftParams(lockScript *types.Script, cotaId []byte, tokenIndex uint32) map[string]interface{} {
+	return map[string]interface{}{
+		"lock_script": LockScript(lockScript),
+		"cota_id":     hexutil.Bytes(cotaId),
+		"token_index": HexUint32(tokenIndex),
+	}
+}
@@ -0,0 +1,106 @@
+package aggregator
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+)
+
+// decodeParams decodes the params of the last request of the method
+func decodeParams(t *testing.T, node *testutil.Node, method string) map[string]interface{} {
+	var params map[string]interface{}
+	if err := json.Unmarshal(node.Params[method], &params); err != nil {
+		t.Fatalf("invalid %s params %s", method, node.Params[method])
+	}
+	return params
+}
+
+func TestHexUint32(t *testing.T) {
+	data, _ := json.Marshal(HexUint32(100))
+	if string(data) != `"0x00000064"` {
+		t.Errorf("MarshalJSON() = %s, want %s", data, `"0x00000064"`)
+	}
+	for _, input := range []string{`"0x00000064"`, `100`} {
+		var n HexUint32
+		if err := json.Unmarshal([]byte(input), &n); err != nil || n != 100 {
+			t.Errorf("UnmarshalJSON(%s) = %d, %v, want 100", input, n, err)
+		}
+	}
+	var b HexByte
+	if err := json.Unmarshal([]byte(`"0x100"`), &b); err == nil {
+		t.Errorf("UnmarshalJSON() should fail with overflow")
+	}
+}
+
+func TestGenerateMintCotaSmt(t *testing.T) {
+	node, server := testutil.NewNode(t)
+	defer server.Close()
+	node.Results["generate_mint_cota_smt"] = `{"smt_root_hash":"0x0102","mint_smt_entry":"0x0304","block_number":100}`
+
+	result, err := NewRPCClient(server.URL).GenerateMintCotaSmtContext(context.Background(), &MintCotaReq{
+		LockScript: LockScript(testutil.Address().Script),
+		CotaId:     []byte{0xaa},
+		OutPoint:   []byte{0xbb},
+		Withdrawals: []MintWithdrawal{
+			{TokenIndex: 1, State: 0, Characteristic: []byte{0xcc}, ToLockScript: []byte{0xdd}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &MintCotaResult{SmtRootHash: []byte{1, 2}, MintSmtEntry: []byte{3, 4}, BlockNumber: 100}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("GenerateMintCotaSmt() = %+v, want %+v", result, want)
+	}
+	params := decodeParams(t, node, "generate_mint_cota_smt")
+	withdrawal := params["withdrawals"].([]interface{})[0].(map[string]interface{})
+	if withdrawal["token_index"] != "0x00000001" || withdrawal["state"] != "0x00" || withdrawal["to_lock_script"] != "0xdd" {
+		t.Errorf("withdrawals = %v", withdrawal)
+	}
+}
+
+func TestGetHoldCotaNft(t *testing.T) {
+	result := `{"total":1,"page_size":10,"block_number":100,"nfts":[{"cota_id":"0xaa","token_index":"0x00000002",
+		"state":"0x00","configure":"0x01","characteristic":"0xcc","name":"nft","description":"","image":"",
+		"audio":"","video":"","model":"","meta_characteristic":"","properties":""}]}`
+	node, server := testutil.NewNode(t)
+	defer server.Close()
+	node.Results["get_hold_cota_nft"] = result
+
+	hold, err := NewRPCClient(server.URL).GetHoldCotaNftContext(context.Background(), testutil.Address().Script, nil, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hold.Total != 1 || len(hold.Nfts) != 1 || hold.Nfts[0].TokenIndex != 2 || hold.Nfts[0].Configure != 1 || hold.Nfts[0].Name != "nft" {
+		t.Errorf("GetHoldCotaNft() = %+v", hold)
+	}
+	params := decodeParams(t, node, "get_hold_cota_nft")
+	if _, ok := params["cota_id"]; ok {
+		t.Errorf("cota_id should be omitted if it is empty")
+	}
+	if params["page_size"] != float64(10) {
+		t.Errorf("page_size = %v, want 10", params["page_size"])
+	}
+}
+
+func TestGetJoyIDInfo(t *testing.T) {
+	result := `{"name":"joy","pub_key":"0x01","alg":"0x01","cota_cell_id":"0x0a","block_number":100,
+		"sub_keys":[{"pub_key":"0x02","credential_id":"0x03","alg":"0x02","front_end":"app.joy.id"}]}`
+	node, server := testutil.NewNode(t)
+	defer server.Close()
+	node.Results["get_joyid_info"] = result
+
+	info, err := NewRPCClient(server.URL).GetJoyIDInfoContext(context.Background(), testutil.Address().Script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "joy" || len(info.Subkeys) != 1 || info.Subkeys[0].FrontEnd != "app.joy.id" {
+		t.Errorf("GetJoyIDInfo() = %+v", info)
+	}
+	if params := decodeParams(t, node, "get_joyid_info"); params["lock_script"] != LockScript(testutil.Address().Script).String() {
+		t.Errorf("lock_script = %v", params["lock_script"])
+	}
+}
@@ -0,0 +1,47 @@
+package aggregator
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// the ext_action of the extension social SMT which sets the guardians
+const (
+	ExtActionAddSocial    byte = 0xF0
+	ExtActionUpdateSocial byte = 0xF1
+)
+
+// SocialRecoveryMode is the recovery_mode of the social extension, and the guardians authorize
+// the recovery with their signatures
+const SocialRecoveryMode byte = 0x00
+
+// ExtensionSocialReq sets the guardians of the JoyID account, and must of the total guardians are
+// required to recover the account
+type ExtensionSocialReq struct {
+	LockScript   hexutil.Bytes   `json:"lock_script"`
+	ExtAction    HexByte         `json:"ext_action"`
+	RecoveryMode HexByte         `json:"recovery_mode"`
+	Must         HexByte         `json:"must"`
+	Total        HexByte         `json:"total"`
+	Signers      []hexutil.Bytes `json:"signers"`
+}
+
+type ExtensionSocialResult struct {
+	SmtRootHash       hexutil.Bytes `json:"smt_root_hash"`
+	ExtensionSmtEntry hexutil.Bytes `json:"extension_smt_entry"`
+	BlockNumber       uint64        `json:"block_number"`
+}
+
+// GenerateExtensionSocialSmt generates the extension SMT entry which adds or updates the guardians
+func (rpc *RPCClient) GenerateExtensionSocialSmt(req *ExtensionSocialReq) (*ExtensionSocialResult, error) {
+	return rpc.GenerateExtensionSocialSmtContext(context.Background(), req)
+}
+
+func (rpc *RPCClient) GenerateExtensionSocialSmtContext(ctx context.Context, req *ExtensionSocialReq) (*ExtensionSocialResult, error) {
+	var result ExtensionSocialResult
+	if err := rpc.Call(ctx, "generate_extension_social_smt", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,148 @@
+package cota
+
+import (
+	"errors"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/collector/builder"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// the first byte of WitnessArgs.InputType of the CoTA cell tells the CoTA action
+const (
+	ActionDefine    byte = 0x01
+	ActionMint      byte = 0x02
+	ActionWithdraw  byte = 0x03
+	ActionClaim     byte = 0x04
+	ActionUpdate    byte = 0x05
+	ActionTransfer  byte = 0x06
+	ActionExtension byte = 0xF0
+)
+
+const (
+	// version + smt_root of the CoTA cell data
+	cotaCellDataVersion = 0x02
+	defaultFeeRate      = 1000
+)
+
+// Builder builds the transactions which update the CoTA cell of the JoyID lock with the SMT entries
+// of the aggregator, and the fee is paid by the capacity of the CoTA cell
+type Builder struct {
+	Network    types.Network
+	Aggregator *aggregator.RPCClient
+	IndexerUrl string
+	// FeeRate is shannons per KB
+	FeeRate uint64
+	// MaxTxSize limits the size of the mint transaction, and a default size is used if it is zero
+	MaxTxSize uint64
+}
+
+func NewBuilder(network types.Network, aggregatorUrl, indexerUrl string) *Builder {
+	return &Builder{
+		Network:    network,
+		Aggregator: aggregator.NewRPCClient(aggregatorUrl),
+		IndexerUrl: indexerUrl,
+		FeeRate:    defaultFeeRate,
+	}
+}
+
+// cotaCellTx describes how the CoTA cell is updated
+type cotaCellTx struct {
+	cotaCell  *indexer.LiveCell
+	smtRoot   []byte
+	action    byte
+	smtEntry  []byte
+	cellDeps  []*types.CellDep
+	unlockCtx *handler.JoyIDUnlockContext
+}
+
+// outPointKey is the last 24 bytes of the serialized out point which identifies the CoTA cell in the SMT
+func outPointKey(outPoint *types.OutPoint) []byte {
+	return outPoint.Serialize()[12:]
+}
+
+// build puts the CoTA cell as the only input and output, and the JoyID lock script group is ready
+// for native or subkey signing with the unlock context
+func (b *Builder) build(c *cotaCellTx) (*transaction.TransactionWithScriptGroups, error) {
+	cotaCell := c.cotaCell
+	if cotaCell.Output.Type == nil {
+		return nil, errors.New("cota cell must have the type script")
+	}
+	txBuilder := builder.NewSimpleTransactionBuilder(b.Network)
+	index := txBuilder.AddInput(&types.CellInput{
+		PreviousOutput: cotaCell.OutPoint,
+		Since:          0x0,
+	})
+	output := &types.CellOutput{
+		Capacity: cotaCell.Output.Capacity,
+		Lock:     cotaCell.Output.Lock,
+		Type:     cotaCell.Output.Type,
+	}
+	outputData := append([]byte{cotaCellDataVersion}, c.smtRoot...)
+	txBuilder.AddOutput(output, outputData)
+	inputType := append([]byte{c.action}, c.smtEntry...)
+	if err := txBuilder.SetWitness(uint(index), types.WitnessTypeInputType, inputType); err != nil {
+		return nil, err
+	}
+	txBuilder.AddCellDep(utils.CotaTypeCellDep(b.Network))
+	for _, cellDep := range c.cellDeps {
+		txBuilder.AddCellDep(cellDep)
+	}
+
+	lockGroup := &transaction.ScriptGroup{
+		Script:       cotaCell.Output.Lock,
+		GroupType:    types.ScriptTypeLock,
+		InputIndices: []uint32{uint32(index)},
+	}
+	typeGroup := &transaction.ScriptGroup{
+		Script:        cotaCell.Output.Type,
+		GroupType:     types.ScriptTypeType,
+		InputIndices:  []uint32{uint32(index)},
+		OutputIndices: []uint32{0},
+	}
+	joyidHandler := handler.NewJoyIDScriptHandler(b.Network)
+	if joyidHandler == nil {
+		return nil, errors.New("unknown network")
+	}
+	var unlockCtx interface{}
+	if c.unlockCtx != nil {
+		unlockCtx = b.withDefaults(c.unlockCtx)
+	}
+	handled, err := joyidHandler.BuildTransaction(txBuilder, lockGroup, unlockCtx)
+	if err != nil {
+		return nil, err
+	}
+	if !handled {
+		return nil, errors.New("cota cell must be locked by the JoyID lock")
+	}
+	txBuilder.AddScriptGroup(lockGroup)
+	txBuilder.AddScriptGroup(typeGroup)
+
+	tx := txBuilder.BuildTransaction()
+	fee := tx.TxView.CalculateFee(b.FeeRate)
+	if output.Capacity < fee || output.Capacity-fee < output.OccupiedCapacity(outputData) {
+		return nil, errors.New("no enough capacity of the cota cell for the fee")
+	}
+	output.Capacity -= fee
+	return tx, nil
+}
+
+// withDefaults fills the aggregator and the indexer of subkey unlock with the ones of the builder
+func (b *Builder) withDefaults(unlockCtx *handler.JoyIDUnlockContext) *handler.JoyIDUnlockContext {
+	if unlockCtx.Mode != signer.SubkeyUnlock {
+		return unlockCtx
+	}
+	ctx := *unlockCtx
+	if ctx.IndexerUrl == "" {
+		ctx.IndexerUrl = b.IndexerUrl
+	}
+	if ctx.Aggregator == nil && ctx.AggregatorUrl == "" {
+		ctx.Aggregator = b.Aggregator
+	}
+	return &ctx
+}
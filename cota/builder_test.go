@@ -0,0 +1,161 @@
+package cota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// newMockNode returns the node whose get_cells also serves the payer cell and the registry cell
+func newMockNode(t *testing.T) (*testutil.Node, *httptest.Server) {
+	node, server := testutil.NewNode(t)
+	node.Handlers["get_cells"] = func(params json.RawMessage) string {
+		return cotaCells(t, params)
+	}
+	return node, server
+}
+
+// cotaCells returns the payer cell for the lock search, the registry cell for the test registry type, or the
+// CoTA cell whose out point tx hash is filled with the first byte of the type args
+func cotaCells(t *testing.T, params json.RawMessage) string {
+	var args []json.RawMessage
+	var searchKey struct {
+		Script     *types.Script    `json:"script"`
+		ScriptType types.ScriptType `json:"script_type"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil || len(args) < 3 || json.Unmarshal(args[0], &searchKey) != nil {
+		t.Errorf("invalid get_cells params %s", params)
+		return "null"
+	}
+	var afterCursor string
+	if len(args) > 3 {
+		json.Unmarshal(args[3], &afterCursor)
+	}
+	if afterCursor != "" && afterCursor != "0x" {
+		return `{"last_cursor":"0x","objects":[]}`
+	}
+
+	script := searchKey.Script
+	cell := map[string]interface{}{
+		"block_number": "0x1",
+		"out_point":    types.OutPoint{TxHash: types.BytesToHash(testutil.BytesOf(32, script.Args[0])), Index: 1},
+		"tx_index":     "0x0",
+	}
+	switch {
+	case searchKey.ScriptType == types.ScriptTypeLock:
+		// the cell of the payer without type script
+		cell["output"] = types.CellOutput{Capacity: 1000_00000000, Lock: script}
+		cell["output_data"] = "0x"
+	case script.CodeHash == testRegistryType.CodeHash:
+		lock := &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeData, Args: []byte{}}
+		cell["output"] = types.CellOutput{Capacity: 500_00000000, Lock: lock, Type: script}
+		cell["output_data"] = utils.BytesTo0xHex(append([]byte{0x01}, make([]byte, 40)...))
+	default:
+		lock := testutil.Address().Script
+		if script.Args[0] != lock.Hash().Bytes()[0] {
+			lock = &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeType, Args: script.Args}
+		}
+		cell["output"] = types.CellOutput{Capacity: 500_00000000, Lock: lock, Type: script}
+		cell["output_data"] = utils.BytesTo0xHex(append([]byte{0x02}, make([]byte, 32)...))
+	}
+	data, _ := json.Marshal(map[string]interface{}{"last_cursor": "0x01", "objects": []interface{}{cell}})
+	return string(data)
+}
+
+func TestBuildTransferTx(t *testing.T) {
+	node, server := newMockNode(t)
+	defer server.Close()
+	senderLockHash := testutil.BytesOf(32, 0x11)
+	node.Results["get_cota_nft_sender"] = fmt.Sprintf(`{"sender_lock_hash":"%s","block_number":1}`, utils.BytesTo0xHex(senderLockHash))
+	node.Results["generate_transfer_cota_smt"] = `{"smt_root_hash":"0x` + utils.BytesToHex(testutil.BytesOf(32, 0xaa)) + `","transfer_smt_entry":"0xbbbb","block_number":1}`
+
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	receiver := &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeType, Args: []byte{0x01}}
+	tx, err := b.BuildTransferTx(context.Background(), testutil.Address(), []Transfer{
+		{Nft{testutil.BytesOf(20, 0x01), 1}, receiver},
+		{Nft{testutil.BytesOf(20, 0x01), 2}, receiver},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txView := tx.TxView
+	if len(txView.Inputs) != 1 || len(txView.Outputs) != 1 || len(tx.ScriptGroups) != 2 {
+		t.Fatalf("transaction should update the only CoTA cell")
+	}
+	if got, want := utils.BytesToHex(txView.OutputsData[0]), "02"+utils.BytesToHex(testutil.BytesOf(32, 0xaa)); got != want {
+		t.Errorf("CoTA cell data = %s, want %s", got, want)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(txView.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := utils.BytesToHex(witnessArgs.InputType); got != "06bbbb" {
+		t.Errorf("WitnessArgs.InputType = %s, want 06bbbb", got)
+	}
+	if len(witnessArgs.Lock) == 0 {
+		t.Errorf("WitnessArgs.Lock should be the placeholder")
+	}
+	fee := txView.CalculateFee(b.FeeRate)
+	if got, want := txView.Outputs[0].Capacity, 500_00000000-fee; got != want {
+		t.Errorf("CoTA cell capacity = %d, want %d", got, want)
+	}
+	hasWithdrawalCellDep := false
+	for _, cellDep := range txView.CellDeps {
+		if cellDep.OutPoint.TxHash == types.BytesToHash(testutil.BytesOf(32, 0x11)) && cellDep.DepType == types.DepTypeCode {
+			hasWithdrawalCellDep = true
+		}
+	}
+	if !hasWithdrawalCellDep {
+		t.Errorf("cell deps should have the CoTA cell of the withdrawal lock")
+	}
+
+	var req aggregator.TransferCotaReq
+	if err := json.Unmarshal(node.Params["generate_transfer_cota_smt"], &req); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(req.WithdrawalLockHash, senderLockHash) || len(req.Transfers) != 2 || req.Transfers[1].TokenIndex != 2 {
+		t.Errorf("transfer request = %+v", req)
+	}
+	if !bytes.Equal(req.TransferOutPoint, txView.Inputs[0].PreviousOutput.Serialize()[12:]) {
+		t.Errorf("transfer_out_point = %x, want the last 24 bytes of the CoTA cell out point", req.TransferOutPoint)
+	}
+}
+
+func TestBuildTransferTxWithDifferentSenders(t *testing.T) {
+	node, server := newMockNode(t)
+	defer server.Close()
+	calls := 0
+	node.Results["get_cota_nft_sender"] = `{"sender_lock_hash":"0x` + utils.BytesToHex(testutil.BytesOf(32, 0x11)) + `","block_number":1}`
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	b.Aggregator = aggregator.NewRPCClient(server.URL, aggregator.WithTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 2 {
+			node.Results["get_cota_nft_sender"] = `{"sender_lock_hash":"0x` + utils.BytesToHex(testutil.BytesOf(32, 0x22)) + `","block_number":1}`
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})))
+	receiver := testutil.Address().Script
+	_, err := b.BuildTransferTx(context.Background(), testutil.Address(), []Transfer{
+		{Nft{testutil.BytesOf(20, 0x01), 1}, receiver},
+		{Nft{testutil.BytesOf(20, 0x02), 1}, receiver},
+	}, nil)
+	if err == nil {
+		t.Errorf("BuildTransferTx() should fail with different senders")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
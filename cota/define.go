@@ -0,0 +1,55 @@
+package cota
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// GenerateCotaId returns the first 20 bytes of blake2b(first_input | output_index), and output_index
+// is the u64 little-endian index of the CoTA cell in the outputs
+func GenerateCotaId(firstInput *types.CellInput, outputIndex uint64) []byte {
+	data := firstInput.Serialize()
+	data = binary.LittleEndian.AppendUint64(data, outputIndex)
+	return blake2b.Blake256(data)[:20]
+}
+
+// BuildDefineTx builds the transaction which defines a CoTA class of the JoyID issuer, and the cota id
+// of the class is returned. Total is the max supply and zero means unlimited.
+func (b *Builder) BuildDefineTx(ctx context.Context, issuer *address.Address, total uint32, configure byte, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, []byte, error) {
+	cotaCell, err := utils.GetCotaLiveCell(b.IndexerUrl, issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the CoTA cell is the first input and the first output of the transaction
+	cotaId := GenerateCotaId(&types.CellInput{PreviousOutput: cotaCell.OutPoint, Since: 0x0}, 0)
+	result, err := b.Aggregator.GenerateDefineCotaSmtContext(ctx, &aggregator.DefineCotaReq{
+		LockScript: aggregator.LockScript(issuer.Script),
+		CotaId:     cotaId,
+		Total:      aggregator.HexUint32(total),
+		Issued:     0,
+		Configure:  aggregator.HexByte(configure),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := b.build(&cotaCellTx{
+		cotaCell:  cotaCell,
+		smtRoot:   result.SmtRootHash,
+		action:    ActionDefine,
+		smtEntry:  result.DefineSmtEntry,
+		unlockCtx: unlockCtx,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, cotaId, nil
+}
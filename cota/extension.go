@@ -0,0 +1,95 @@
+package cota
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// BuildExtensionTx builds the transaction which adds or updates the subkeys of the JoyID address with
+// the ext action, e.g. aggregator.ExtActionAddSubkey
+func (b *Builder) BuildExtensionTx(ctx context.Context, addr *address.Address, extAction byte, subkeys []aggregator.ExtensionSubkey, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	switch extAction {
+	case aggregator.ExtActionAddSubkey, aggregator.ExtActionUpdateSubkey:
+	default:
+		return nil, errors.New("unknown extension action")
+	}
+	if len(subkeys) == 0 {
+		return nil, errors.New("subkeys cannot be empty")
+	}
+	cotaCell, err := utils.GetCotaLiveCell(b.IndexerUrl, addr)
+	if err != nil {
+		return nil, err
+	}
+	result, err := b.Aggregator.GenerateExtensionSubkeySmtContext(ctx, &aggregator.ExtensionSubkeyReq{
+		LockScript: aggregator.LockScript(addr.Script),
+		ExtAction:  aggregator.HexByte(extAction),
+		Subkeys:    subkeys,
+	})
+	if err != nil {
+		return nil, err
+	}
+	smtRoot, err := utils.HexToBytes(result.SmtRootHash)
+	if err != nil {
+		return nil, err
+	}
+	smtEntry, err := utils.HexToBytes(result.ExtensionSmtEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.build(&cotaCellTx{
+		cotaCell:  cotaCell,
+		smtRoot:   smtRoot,
+		action:    ActionExtension,
+		smtEntry:  smtEntry,
+		unlockCtx: unlockCtx,
+	})
+}
+
+// BuildSocialTx builds the transaction which adds or updates the guardians of the JoyID address with
+// the ext action, e.g. aggregator.ExtActionAddSocial, and must of the guardians are required to recover the account
+func (b *Builder) BuildSocialTx(ctx context.Context, addr *address.Address, extAction byte, must uint8, guardians []*types.Script, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	if extAction != aggregator.ExtActionAddSocial && extAction != aggregator.ExtActionUpdateSocial {
+		return nil, errors.New("guardians can only be added or updated")
+	}
+	if len(guardians) == 0 || len(guardians) > math.MaxUint8 {
+		return nil, errors.New("invalid number of guardians")
+	}
+	if must == 0 || int(must) > len(guardians) {
+		return nil, errors.New("must should be between 1 and the number of guardians")
+	}
+	cotaCell, err := utils.GetCotaLiveCell(b.IndexerUrl, addr)
+	if err != nil {
+		return nil, err
+	}
+	req := &aggregator.ExtensionSocialReq{
+		LockScript:   aggregator.LockScript(addr.Script),
+		ExtAction:    aggregator.HexByte(extAction),
+		RecoveryMode: aggregator.HexByte(aggregator.SocialRecoveryMode),
+		Must:         aggregator.HexByte(must),
+		Total:        aggregator.HexByte(len(guardians)),
+	}
+	for _, guardian := range guardians {
+		req.Signers = append(req.Signers, aggregator.LockScript(guardian))
+	}
+	result, err := b.Aggregator.GenerateExtensionSocialSmtContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.build(&cotaCellTx{
+		cotaCell:  cotaCell,
+		smtRoot:   result.SmtRootHash,
+		action:    ActionExtension,
+		smtEntry:  result.ExtensionSmtEntry,
+		unlockCtx: unlockCtx,
+	})
+}
@@ -0,0 +1,138 @@
+package cota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const (
+	// the max count of NFTs minted by one transaction before the transaction size is checked
+	maxMintBatch = 500
+	// the max transaction size accepted by the tx pool of CKB
+	defaultMaxTxSize  = 512_000
+	characteristicLen = 20
+)
+
+// MintReceiver receives the NFT minted with the state and characteristic
+type MintReceiver struct {
+	ToLockScript   *types.Script
+	State          byte
+	Characteristic []byte
+}
+
+// BuildMintTx builds the transaction which mints the NFTs of the class to the first receivers from the next
+// token index of the class, and the count of the receivers minted by the transaction is returned. The
+// receivers are split by the transaction size and the supply left of the class, because the next mint
+// entries are generated from the SMT root of the committed transaction. The caller loops until all the
+// receivers are minted:
+//
+//	for len(receivers) > 0 {
+//		tx, count, err := b.BuildMintTx(ctx, issuer, cotaId, receivers, nil)
+//		// sign and send tx, and wait for it to be committed and indexed by the aggregator
+//		receivers = receivers[count:]
+//	}
+func (b *Builder) BuildMintTx(ctx context.Context, issuer *address.Address, cotaId []byte, receivers []MintReceiver, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, int, error) {
+	if len(receivers) == 0 {
+		return nil, 0, errors.New("receivers cannot be empty")
+	}
+	define, err := b.Aggregator.GetDefineInfoContext(ctx, cotaId)
+	if err != nil {
+		return nil, 0, err
+	}
+	issued := uint32(define.Issued)
+	count := len(receivers)
+	if count > maxMintBatch {
+		count = maxMintBatch
+	}
+	if define.Total != 0 {
+		if issued >= uint32(define.Total) {
+			return nil, 0, fmt.Errorf("all the %d NFTs of the class are issued", define.Total)
+		}
+		if left := int(uint32(define.Total) - issued); count > left {
+			count = left
+		}
+	}
+	cotaCell, err := utils.GetCotaLiveCell(b.IndexerUrl, issuer)
+	if err != nil {
+		return nil, 0, err
+	}
+	maxTxSize := b.MaxTxSize
+	if maxTxSize == 0 {
+		maxTxSize = defaultMaxTxSize
+	}
+
+	var lastCount int
+	var lastSize uint64
+	for {
+		tx, err := b.buildMintTx(ctx, issuer, cotaCell, cotaId, issued, receivers[:count], unlockCtx)
+		if err != nil {
+			return nil, 0, err
+		}
+		size := tx.TxView.SizeInBlock()
+		if size <= maxTxSize {
+			return tx, count, nil
+		}
+		if count == 1 {
+			return nil, 0, errors.New("mint transaction exceeds the max transaction size")
+		}
+		// the size grows linearly with the mint entries, so the count is estimated with the size of each
+		// entry from the last two sizes instead of halving, which generates the entries fewer times
+		next := int(uint64(count) * maxTxSize / size)
+		if lastCount > count && lastSize > size {
+			entrySize := (lastSize - size + uint64(lastCount-count) - 1) / uint64(lastCount-count)
+			if entriesSize := entrySize * uint64(count); entriesSize < size && size-entriesSize < maxTxSize {
+				next = int((maxTxSize - (size - entriesSize)) / entrySize)
+			}
+		}
+		if next >= count {
+			next = count - 1
+		}
+		if next < 1 {
+			next = 1
+		}
+		lastCount, lastSize, count = count, size, next
+	}
+}
+
+func (b *Builder) buildMintTx(ctx context.Context, issuer *address.Address, cotaCell *indexer.LiveCell, cotaId []byte, issued uint32, receivers []MintReceiver, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	req := &aggregator.MintCotaReq{
+		LockScript: aggregator.LockScript(issuer.Script),
+		CotaId:     cotaId,
+		OutPoint:   outPointKey(cotaCell.OutPoint),
+	}
+	for i, receiver := range receivers {
+		if receiver.ToLockScript == nil {
+			return nil, errors.New("receiver lock script cannot be empty")
+		}
+		characteristic := receiver.Characteristic
+		if characteristic == nil {
+			characteristic = make([]byte, characteristicLen)
+		}
+		req.Withdrawals = append(req.Withdrawals, aggregator.MintWithdrawal{
+			TokenIndex:     aggregator.HexUint32(issued + uint32(i)),
+			State:          aggregator.HexByte(receiver.State),
+			Characteristic: characteristic,
+			ToLockScript:   aggregator.LockScript(receiver.ToLockScript),
+		})
+	}
+	result, err := b.Aggregator.GenerateMintCotaSmtContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return b.build(&cotaCellTx{
+		cotaCell:  cotaCell,
+		smtRoot:   result.SmtRootHash,
+		action:    ActionMint,
+		smtEntry:  result.MintSmtEntry,
+		unlockCtx: unlockCtx,
+	})
+}
@@ -0,0 +1,104 @@
+package cota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func TestBuildDefineTx(t *testing.T) {
+	node, server := newMockNode(t)
+	defer server.Close()
+	node.Results["generate_define_cota_smt"] = `{"smt_root_hash":"0x` + utils.BytesToHex(testutil.BytesOf(32, 0xaa)) + `","define_smt_entry":"0x0101","block_number":1}`
+
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	tx, cotaId, err := b.BuildDefineTx(context.Background(), testutil.Address(), 100, 0x00, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := GenerateCotaId(tx.TxView.Inputs[0], 0); !bytes.Equal(cotaId, want) {
+		t.Errorf("cota id = %x, want %x", cotaId, want)
+	}
+	var req aggregator.DefineCotaReq
+	if err := json.Unmarshal(node.Params["generate_define_cota_smt"], &req); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(req.CotaId, cotaId) || req.Total != 100 || req.Issued != 0 {
+		t.Errorf("define request = %+v", req)
+	}
+	witnessArgs, _ := types.DeserializeWitnessArgs(tx.TxView.Witnesses[0])
+	if got := utils.BytesToHex(witnessArgs.InputType); got != "010101" {
+		t.Errorf("WitnessArgs.InputType = %s, want 010101", got)
+	}
+}
+
+func TestGenerateCotaId(t *testing.T) {
+	input := &types.CellInput{PreviousOutput: &types.OutPoint{TxHash: types.BytesToHash(testutil.BytesOf(32, 0x01)), Index: 0}}
+	if len(GenerateCotaId(input, 0)) != 20 {
+		t.Errorf("cota id must be 20 bytes")
+	}
+	if bytes.Equal(GenerateCotaId(input, 0), GenerateCotaId(input, 1)) {
+		t.Errorf("cota ids of different output indices should be different")
+	}
+}
+
+func TestBuildMintTxWithSplitting(t *testing.T) {
+	node, server := newMockNode(t)
+	defer server.Close()
+	node.Results["get_define_info"] = `{"total":"0x00000064","issued":"0x00000005","configure":"0x00","block_number":1}`
+	var mintCount int
+	node.Handlers["generate_mint_cota_smt"] = func(params json.RawMessage) string {
+		var req aggregator.MintCotaReq
+		if err := json.Unmarshal(params, &req); err != nil {
+			t.Errorf("invalid generate_mint_cota_smt params %s", params)
+			return "null"
+		}
+		mintCount = len(req.Withdrawals)
+		// the entry grows with the withdrawals
+		entry := utils.BytesTo0xHex(make([]byte, 1000*len(req.Withdrawals)))
+		return fmt.Sprintf(`{"smt_root_hash":"0x%s","mint_smt_entry":"%s","block_number":1}`, utils.BytesToHex(testutil.BytesOf(32, 0xaa)), entry)
+	}
+
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	b.MaxTxSize = 3500
+	receivers := make([]MintReceiver, 5)
+	for i := range receivers {
+		receivers[i] = MintReceiver{ToLockScript: testutil.Address().Script}
+	}
+	tx, count, err := b.BuildMintTx(context.Background(), testutil.Address(), testutil.BytesOf(20, 0x01), receivers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || mintCount != 2 {
+		t.Errorf("BuildMintTx() count = %d, want 2", count)
+	}
+	if size := tx.TxView.SizeInBlock(); size > b.MaxTxSize {
+		t.Errorf("transaction size = %d, want at most %d", size, b.MaxTxSize)
+	}
+	var req aggregator.MintCotaReq
+	if err := json.Unmarshal(node.Params["generate_mint_cota_smt"], &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Withdrawals[0].TokenIndex != 5 || req.Withdrawals[1].TokenIndex != 6 {
+		t.Errorf("token indices should start from the issued count")
+	}
+	if len(req.Withdrawals[0].Characteristic) != 20 {
+		t.Errorf("characteristic should be 20 bytes")
+	}
+
+	node.Results["get_define_info"] = `{"total":"0x00000006","issued":"0x00000005","configure":"0x00","block_number":1}`
+	if _, count, err := b.BuildMintTx(context.Background(), testutil.Address(), testutil.BytesOf(20, 0x01), receivers, nil); err != nil || count != 1 {
+		t.Errorf("BuildMintTx() count = %d, %v, want 1 for the supply left", count, err)
+	}
+	node.Results["get_define_info"] = `{"total":"0x00000005","issued":"0x00000005","configure":"0x00","block_number":1}`
+	if _, _, err := b.BuildMintTx(context.Background(), testutil.Address(), testutil.BytesOf(20, 0x01), receivers, nil); err == nil {
+		t.Errorf("BuildMintTx() should fail if all the NFTs are issued")
+	}
+}
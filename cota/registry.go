@@ -0,0 +1,213 @@
+package cota
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/collector"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/collector/builder"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const (
+	// the capacity of the new CoTA cell which pays the fees of the CoTA transactions later
+	defaultCotaCellCapacity = 150_00000000
+	// version + registry_smt_root of the registry cell data, and the account number follows in the newer version
+	registryCellDataPrefixLen = 33
+)
+
+// Registry is the deployment of the CoTA registry cell which every CoTA cell must be registered with
+type Registry struct {
+	// Aggregator is the client of the CoTA registry aggregator
+	Aggregator *aggregator.RPCClient
+	// TypeScript is the type script of the registry cell
+	TypeScript *types.Script
+	// CellDeps are the cell deps of the registry type script and the lock script of the registry cell
+	CellDeps []*types.CellDep
+	// CotaCellCapacity is the capacity of each new CoTA cell, and a default capacity is used if it is zero
+	CotaCellCapacity uint64
+}
+
+// BuildRegistryTx builds the transaction which registers and creates the CoTA cells of the JoyID accounts.
+// The capacity of the CoTA cells and the fee are paid by the payer, whose lock script group is built with
+// the contexts, e.g. *handler.JoyIDUnlockContext of the JoyID payer.
+func (b *Builder) BuildRegistryTx(ctx context.Context, registry *Registry, accounts []*address.Address, payer *address.Address, contexts ...interface{}) (*transaction.TransactionWithScriptGroups, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("accounts cannot be empty")
+	}
+	if registry == nil || registry.Aggregator == nil || registry.TypeScript == nil {
+		return nil, errors.New("registry aggregator and type script cannot be empty")
+	}
+	client, err := rpc.Dial(b.IndexerUrl)
+	if err != nil {
+		return nil, err
+	}
+	registryCell, err := getRegistryCell(ctx, client, registry.TypeScript)
+	if err != nil {
+		return nil, err
+	}
+
+	lockHashes := make([]types.Hash, 0, len(accounts))
+	for _, account := range accounts {
+		lockHashes = append(lockHashes, account.Script.Hash())
+	}
+	result, err := registry.Aggregator.RegisterCotaCellsContext(ctx, lockHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	payerAddr, err := payer.Encode()
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := collector.NewLiveCellIteratorFromAddress(client, payerAddr)
+	if err != nil {
+		return nil, err
+	}
+	txBuilder := builder.NewSimpleTransactionBuilder(b.Network)
+	if txBuilder == nil {
+		return nil, errors.New("unknown network")
+	}
+	txBuilder.Register(handler.NewJoyIDScriptHandler(b.Network))
+	txBuilder.Register(&registryHandler{registry: registry, registrySmtEntry: result.RegistrySmtEntry})
+
+	registryIndex := txBuilder.AddInput(&types.CellInput{PreviousOutput: registryCell.OutPoint, Since: 0x0})
+	registryOutput := &types.CellOutput{
+		Capacity: registryCell.Output.Capacity,
+		Lock:     registryCell.Output.Lock,
+		Type:     registryCell.Output.Type,
+	}
+	txBuilder.AddOutput(registryOutput, registryCellData(registryCell.OutputData, result.SmtRootHash, result.OutputAccountNum))
+	cotaCellCapacity := registry.CotaCellCapacity
+	if cotaCellCapacity == 0 {
+		cotaCellCapacity = defaultCotaCellCapacity
+	}
+	for _, account := range accounts {
+		txBuilder.AddOutput(&types.CellOutput{
+			Capacity: cotaCellCapacity,
+			Lock:     account.Script,
+			Type:     utils.CotaTypeScript(b.Network, account.Script.Hash()),
+		}, []byte{0x00})
+	}
+	changeIndex := txBuilder.AddOutput(&types.CellOutput{Capacity: 0, Lock: payer.Script}, []byte{})
+	txBuilder.AddCellDep(utils.CotaTypeCellDep(b.Network))
+
+	registryGroup := &transaction.ScriptGroup{
+		Script:        registryCell.Output.Type,
+		GroupType:     types.ScriptTypeType,
+		InputIndices:  []uint32{uint32(registryIndex)},
+		OutputIndices: []uint32{0},
+	}
+	if err := executeHandlers(txBuilder, registryGroup, nil); err != nil {
+		return nil, err
+	}
+	txBuilder.AddScriptGroup(&transaction.ScriptGroup{
+		Script:       registryCell.Output.Lock,
+		GroupType:    types.ScriptTypeLock,
+		InputIndices: []uint32{uint32(registryIndex)},
+	})
+	txBuilder.AddScriptGroup(registryGroup)
+	payerGroup := &transaction.ScriptGroup{Script: payer.Script, GroupType: types.ScriptTypeLock}
+	txBuilder.AddScriptGroup(payerGroup)
+
+	// the payer pays the capacity of the CoTA cells and the fee
+	outputsCapacity := uint64(len(accounts)) * cotaCellCapacity
+	inputsCapacity := uint64(0)
+	for iterator.HasNext() {
+		cell := iterator.Next()
+		if cell.Output.Type != nil {
+			continue
+		}
+		index := txBuilder.AddInput(&types.CellInput{PreviousOutput: cell.OutPoint, Since: 0x0})
+		payerGroup.InputIndices = append(payerGroup.InputIndices, uint32(index))
+		if err := executeHandlers(txBuilder, payerGroup, contexts...); err != nil {
+			return nil, err
+		}
+		inputsCapacity += cell.Output.Capacity
+
+		tx := txBuilder.BuildTransaction()
+		fee := tx.TxView.CalculateFee(b.FeeRate)
+		if inputsCapacity < outputsCapacity+fee {
+			continue
+		}
+		changeOutput := tx.TxView.Outputs[changeIndex]
+		changeCapacity := inputsCapacity - outputsCapacity - fee
+		if changeCapacity >= changeOutput.OccupiedCapacity(tx.TxView.OutputsData[changeIndex]) {
+			changeOutput.Capacity = changeCapacity
+			return tx, nil
+		}
+	}
+	return nil, errors.New("no enough capacity of the payer")
+}
+
+// executeHandlers runs the script handlers with every context like the transaction builder of ckb-sdk-go
+func executeHandlers(txBuilder *builder.SimpleTransactionBuilder, group *transaction.ScriptGroup, contexts ...interface{}) error {
+	if len(contexts) == 0 {
+		contexts = append(contexts, nil)
+	}
+	for _, scriptHandler := range txBuilder.ScriptHandlers {
+		for _, c := range contexts {
+			if _, err := scriptHandler.BuildTransaction(txBuilder, group, c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getRegistryCell(ctx context.Context, client rpc.Client, typeScript *types.Script) (*indexer.LiveCell, error) {
+	searchKey := &indexer.SearchKey{
+		Script:     typeScript,
+		ScriptType: types.ScriptTypeType,
+		WithData:   true,
+	}
+	resp, err := client.GetCells(ctx, searchKey, indexer.SearchOrderAsc, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Objects) == 0 {
+		return nil, errors.New("registry cell doesn't exist")
+	}
+	return resp.Objects[0], nil
+}
+
+// registryCellData keeps the version of the registry cell data, and appends the account number
+// as u64 big-endian if the registry cell data has it
+func registryCellData(data, smtRoot []byte, accountNum uint64) []byte {
+	version := byte(0x00)
+	if len(data) > 0 {
+		version = data[0]
+	}
+	newData := append([]byte{version}, smtRoot...)
+	if len(data) > registryCellDataPrefixLen {
+		newData = binary.BigEndian.AppendUint64(newData, accountNum)
+	}
+	return newData
+}
+
+// registryHandler sets the registry SMT entry into WitnessArgs.InputType of the registry cell
+type registryHandler struct {
+	registry         *Registry
+	registrySmtEntry []byte
+}
+
+func (r *registryHandler) BuildTransaction(builder collector.TransactionBuilder, group *transaction.ScriptGroup, context interface{}) (bool, error) {
+	if group == nil || group.GroupType != types.ScriptTypeType || !group.Script.Equals(r.registry.TypeScript) || len(group.InputIndices) == 0 {
+		return false, nil
+	}
+	if err := builder.SetWitness(uint(group.InputIndices[0]), types.WitnessTypeInputType, r.registrySmtEntry); err != nil {
+		return false, err
+	}
+	for _, cellDep := range r.registry.CellDeps {
+		builder.AddCellDep(cellDep)
+	}
+	return true, nil
+}
@@ -0,0 +1,85 @@
+package cota
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/systemscript"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+var testRegistryType = &types.Script{
+	CodeHash: types.BytesToHash(testutil.BytesOf(32, 0x77)),
+	HashType: types.HashTypeType,
+	Args:     testutil.BytesOf(32, 0x78),
+}
+
+func TestBuildRegistryTx(t *testing.T) {
+	node, server := newMockNode(t)
+	defer server.Close()
+	node.Results["register_cota_cells"] = `{"smt_root_hash":"0x` + utils.BytesToHex(testutil.BytesOf(32, 0xaa)) + `","registry_smt_entry":"0xffff","output_account_num":9,"block_number":1}`
+
+	payer := &address.Address{
+		Script: &types.Script{
+			CodeHash: systemscript.GetCodeHash(types.NetworkTest, systemscript.Secp256k1Blake160SighashAll),
+			HashType: types.HashTypeType,
+			Args:     testutil.BytesOf(20, 0x05),
+		},
+		Network: types.NetworkTest,
+	}
+	registry := &Registry{
+		Aggregator: aggregator.NewRPCClient(server.URL),
+		TypeScript: testRegistryType,
+		CellDeps:   []*types.CellDep{{OutPoint: &types.OutPoint{TxHash: types.BytesToHash(testutil.BytesOf(32, 0x79))}, DepType: types.DepTypeCode}},
+	}
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	tx, err := b.BuildRegistryTx(context.Background(), registry, []*address.Address{testutil.Address()}, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txView := tx.TxView
+	if len(txView.Inputs) != 2 || len(txView.Outputs) != 3 {
+		t.Fatalf("transaction should have registry and payer inputs, and registry, CoTA and change outputs")
+	}
+	wantData := append([]byte{0x01}, testutil.BytesOf(32, 0xaa)...)
+	wantData = append(wantData, 0, 0, 0, 0, 0, 0, 0, 9)
+	if got := utils.BytesToHex(txView.OutputsData[0]); got != utils.BytesToHex(wantData) {
+		t.Errorf("registry cell data = %s, want %x", got, wantData)
+	}
+	cotaOutput := txView.Outputs[1]
+	if !cotaOutput.Lock.Equals(testutil.Address().Script) || !cotaOutput.Type.Equals(utils.CotaTypeScript(types.NetworkTest, testutil.Address().Script.Hash())) {
+		t.Errorf("CoTA cell should be locked by the account with the CoTA type args of the lock hash")
+	}
+	if cotaOutput.Capacity != defaultCotaCellCapacity || utils.BytesToHex(txView.OutputsData[1]) != "00" {
+		t.Errorf("CoTA cell = %d, %x", cotaOutput.Capacity, txView.OutputsData[1])
+	}
+	fee := txView.CalculateFee(b.FeeRate)
+	if got, want := txView.Outputs[2].Capacity, uint64(1000_00000000-defaultCotaCellCapacity)-fee; got != want {
+		t.Errorf("change capacity = %d, want %d", got, want)
+	}
+	registryWitness, err := types.DeserializeWitnessArgs(txView.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utils.BytesToHex(registryWitness.InputType) != "ffff" {
+		t.Errorf("registry WitnessArgs.InputType = %x, want ffff", registryWitness.InputType)
+	}
+	payerWitness, err := types.DeserializeWitnessArgs(txView.Witnesses[1])
+	if err != nil || len(payerWitness.Lock) != 65 {
+		t.Errorf("payer witness should have the secp256k1 placeholder")
+	}
+
+	var lockHashes []types.Hash
+	if err := json.Unmarshal(node.Params["register_cota_cells"], &lockHashes); err != nil {
+		t.Fatal(err)
+	}
+	if len(lockHashes) != 1 || lockHashes[0] != testutil.Address().Script.Hash() {
+		t.Errorf("register_cota_cells params = %v", lockHashes)
+	}
+}
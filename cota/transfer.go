@@ -0,0 +1,86 @@
+package cota
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// Nft is the CoTA NFT identified by the cota id and the token index
+type Nft struct {
+	CotaId     []byte
+	TokenIndex uint32
+}
+
+// Transfer sends the NFT to the receiver lock script
+type Transfer struct {
+	Nft
+	ToLockScript *types.Script
+}
+
+// BuildTransferTx builds the transaction which transfers the NFTs claimable by the JoyID address to the
+// receivers. The NFTs must be withdrawn to the address by the same sender, whose lock hash is queried
+// from the aggregator. The unlock context is native unlock if it is nil.
+func (b *Builder) BuildTransferTx(ctx context.Context, addr *address.Address, transfers []Transfer, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	if len(transfers) == 0 {
+		return nil, errors.New("transfers cannot be empty")
+	}
+	var withdrawalLockHash []byte
+	for _, transfer := range transfers {
+		sender, err := b.Aggregator.GetCotaNftSenderContext(ctx, addr.Script, transfer.CotaId, transfer.TokenIndex)
+		if err != nil {
+			return nil, err
+		}
+		if withdrawalLockHash == nil {
+			withdrawalLockHash = sender.SenderLockHash
+		} else if !bytes.Equal(withdrawalLockHash, sender.SenderLockHash) {
+			return nil, fmt.Errorf("NFTs to transfer must be withdrawn by the same sender, %x and %x", withdrawalLockHash, sender.SenderLockHash)
+		}
+	}
+
+	cotaCell, err := utils.GetCotaLiveCell(b.IndexerUrl, addr)
+	if err != nil {
+		return nil, err
+	}
+	withdrawalCellDep, err := b.withdrawalCotaCellDep(types.BytesToHash(withdrawalLockHash), addr.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &aggregator.TransferCotaReq{
+		LockScript:         aggregator.LockScript(addr.Script),
+		WithdrawalLockHash: withdrawalLockHash,
+		TransferOutPoint:   outPointKey(cotaCell.OutPoint),
+	}
+	for _, transfer := range transfers {
+		if transfer.ToLockScript == nil {
+			return nil, errors.New("receiver lock script cannot be empty")
+		}
+		req.Transfers = append(req.Transfers, aggregator.TransferNft{
+			CotaId:       transfer.CotaId,
+			TokenIndex:   aggregator.HexUint32(transfer.TokenIndex),
+			ToLockScript: aggregator.LockScript(transfer.ToLockScript),
+		})
+	}
+	result, err := b.Aggregator.GenerateTransferCotaSmtContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.build(&cotaCellTx{
+		cotaCell:  cotaCell,
+		smtRoot:   result.SmtRootHash,
+		action:    ActionTransfer,
+		smtEntry:  result.TransferSmtEntry,
+		cellDeps:  []*types.CellDep{withdrawalCellDep},
+		unlockCtx: unlockCtx,
+	})
+}
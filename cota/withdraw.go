@@ -0,0 +1,103 @@
+package cota
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// BuildWithdrawTx builds the transaction which withdraws the NFTs held by the JoyID address of the sender
+// to the receivers, and the receivers claim them with BuildClaimTx
+func (b *Builder) BuildWithdrawTx(ctx context.Context, sender *address.Address, withdrawals []Transfer, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	if len(withdrawals) == 0 {
+		return nil, errors.New("withdrawals cannot be empty")
+	}
+	cotaCell, err := utils.GetCotaLiveCell(b.IndexerUrl, sender)
+	if err != nil {
+		return nil, err
+	}
+	req := &aggregator.WithdrawalCotaReq{
+		LockScript: aggregator.LockScript(sender.Script),
+		OutPoint:   outPointKey(cotaCell.OutPoint),
+	}
+	for _, withdrawal := range withdrawals {
+		if withdrawal.ToLockScript == nil {
+			return nil, errors.New("receiver lock script cannot be empty")
+		}
+		req.Withdrawals = append(req.Withdrawals, aggregator.WithdrawalNft{
+			CotaId:       withdrawal.CotaId,
+			TokenIndex:   aggregator.HexUint32(withdrawal.TokenIndex),
+			ToLockScript: aggregator.LockScript(withdrawal.ToLockScript),
+		})
+	}
+	result, err := b.Aggregator.GenerateWithdrawalCotaSmtContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.build(&cotaCellTx{
+		cotaCell:  cotaCell,
+		smtRoot:   result.SmtRootHash,
+		action:    ActionWithdraw,
+		smtEntry:  result.WithdrawalSmtEntry,
+		unlockCtx: unlockCtx,
+	})
+}
+
+// BuildClaimTx builds the transaction which claims the NFTs withdrawn by the sender into the CoTA cell
+// of the JoyID address of the claimer
+func (b *Builder) BuildClaimTx(ctx context.Context, claimer, sender *address.Address, nfts []Nft, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	if len(nfts) == 0 {
+		return nil, errors.New("NFTs to claim cannot be empty")
+	}
+	cotaCell, err := utils.GetCotaLiveCell(b.IndexerUrl, claimer)
+	if err != nil {
+		return nil, err
+	}
+	withdrawalLockHash := sender.Script.Hash()
+	withdrawalCellDep, err := b.withdrawalCotaCellDep(withdrawalLockHash, claimer.Network)
+	if err != nil {
+		return nil, err
+	}
+	req := &aggregator.ClaimCotaReq{
+		LockScript:         aggregator.LockScript(claimer.Script),
+		WithdrawalLockHash: withdrawalLockHash.Bytes(),
+	}
+	for _, nft := range nfts {
+		req.Claims = append(req.Claims, aggregator.ClaimNft{
+			CotaId:     nft.CotaId,
+			TokenIndex: aggregator.HexUint32(nft.TokenIndex),
+		})
+	}
+	result, err := b.Aggregator.GenerateClaimCotaSmtContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.build(&cotaCellTx{
+		cotaCell:  cotaCell,
+		smtRoot:   result.SmtRootHash,
+		action:    ActionClaim,
+		smtEntry:  result.ClaimSmtEntry,
+		cellDeps:  []*types.CellDep{withdrawalCellDep},
+		unlockCtx: unlockCtx,
+	})
+}
+
+// withdrawalCotaCellDep returns the CoTA cell of the sender which proves the withdrawals of the NFTs
+func (b *Builder) withdrawalCotaCellDep(withdrawalLockHash types.Hash, network types.Network) (*types.CellDep, error) {
+	withdrawalCotaCell, err := utils.GetCotaLiveCellByLockHash(b.IndexerUrl, withdrawalLockHash, network)
+	if err != nil {
+		return nil, err
+	}
+	return &types.CellDep{
+		OutPoint: withdrawalCotaCell.OutPoint,
+		DepType:  types.DepTypeCode,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package cota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func TestBuildWithdrawTx(t *testing.T) {
+	node, server := newMockNode(t)
+	defer server.Close()
+	node.Results["generate_withdrawal_cota_smt"] = `{"smt_root_hash":"0x` + utils.BytesToHex(testutil.BytesOf(32, 0xaa)) + `","withdrawal_smt_entry":"0xcccc","block_number":1}`
+
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	receiver := &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeType, Args: []byte{0x01}}
+	tx, err := b.BuildWithdrawTx(context.Background(), testutil.Address(), []Transfer{{Nft{testutil.BytesOf(20, 0x01), 3}, receiver}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.TxView.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := utils.BytesToHex(witnessArgs.InputType); got != "03cccc" {
+		t.Errorf("WitnessArgs.InputType = %s, want 03cccc", got)
+	}
+
+	var req aggregator.WithdrawalCotaReq
+	if err := json.Unmarshal(node.Params["generate_withdrawal_cota_smt"], &req); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(req.OutPoint, tx.TxView.Inputs[0].PreviousOutput.Serialize()[12:]) {
+		t.Errorf("out_point = %x, want the last 24 bytes of the CoTA cell out point", req.OutPoint)
+	}
+	if len(req.Withdrawals) != 1 || !bytes.Equal(req.Withdrawals[0].ToLockScript, receiver.Serialize()) {
+		t.Errorf("withdrawals = %+v", req.Withdrawals)
+	}
+
+	if _, err := b.BuildWithdrawTx(context.Background(), testutil.Address(), nil, nil); err == nil {
+		t.Errorf("BuildWithdrawTx() should fail with empty withdrawals")
+	}
+}
+
+func TestBuildClaimTxWithSubkeyUnlock(t *testing.T) {
+	node, server := newMockNode(t)
+	defer server.Close()
+	node.Results["generate_claim_cota_smt"] = `{"smt_root_hash":"0x` + utils.BytesToHex(testutil.BytesOf(32, 0xaa)) + `","claim_smt_entry":"0xdddd","block_number":1}`
+	node.Results["generate_subkey_unlock_smt"] = `{"unlock_entry":"0xeeee","block_number":1}`
+
+	sender := &address.Address{
+		Script:  &types.Script{CodeHash: types.Hash{}, HashType: types.HashTypeType, Args: []byte{0x02}},
+		Network: types.NetworkTest,
+	}
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	tx, err := b.BuildClaimTx(context.Background(), testutil.Address(), sender, []Nft{{testutil.BytesOf(20, 0x01), 3}}, &handler.JoyIDUnlockContext{
+		Mode:             signer.SubkeyUnlock,
+		Alg:              alg.Secp256k1,
+		SubkeyPubkeyHash: testutil.BytesOf(20, 0x03),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.TxView.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := utils.BytesToHex(witnessArgs.InputType); got != "04dddd" {
+		t.Errorf("WitnessArgs.InputType = %s, want 04dddd", got)
+	}
+	if got := utils.BytesToHex(witnessArgs.OutputType); got != "eeee" {
+		t.Errorf("WitnessArgs.OutputType = %s, want the subkey unlock entry", got)
+	}
+	if len(witnessArgs.Lock) != 86 {
+		t.Errorf("WitnessArgs.Lock length = %d, want the secp256k1 placeholder", len(witnessArgs.Lock))
+	}
+
+	var req aggregator.ClaimCotaReq
+	if err := json.Unmarshal(node.Params["generate_claim_cota_smt"], &req); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(req.WithdrawalLockHash, sender.Script.Hash().Bytes()) {
+		t.Errorf("withdrawal_lock_hash = %x, want %x", req.WithdrawalLockHash, sender.Script.Hash().Bytes())
+	}
+	withdrawalCell := types.BytesToHash(testutil.BytesOf(32, sender.Script.Hash().Bytes()[0]))
+	hasWithdrawalCellDep := false
+	for _, cellDep := range tx.TxView.CellDeps {
+		if cellDep.OutPoint.TxHash == withdrawalCell {
+			hasWithdrawalCellDep = true
+		}
+	}
+	if !hasWithdrawalCellDep {
+		t.Errorf("cell deps should have the CoTA cell of the sender")
+	}
+}
@@ -0,0 +1,124 @@
+// Package eckey parses the private scalars of the elliptic curve keys for the secp256k1 and secp256r1 packages
+package eckey
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	OIDSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	OIDSecp256r1 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// ecPrivateKey is the SEC1 EC private key of RFC 5915
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 is the PKCS#8 private key of RFC 5208
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// Scalar checks the big-endian private scalar is in [1, n-1]
+func Scalar(raw []byte, n *big.Int) (*big.Int, error) {
+	if len(raw) != (n.BitLen()+7)/8 {
+		return nil, fmt.Errorf("private key must be %d bytes", (n.BitLen()+7)/8)
+	}
+	d := new(big.Int).SetBytes(raw)
+	if d.Sign() == 0 || d.Cmp(n) >= 0 {
+		return nil, errors.New("private key is out of the range of the curve order")
+	}
+	return d, nil
+}
+
+// Hex decodes the hex private key with or without 0x prefix, and the leading zero bytes may be omitted
+func Hex(privKey string, n *big.Int) ([]byte, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(privKey), "0x")
+	if len(h)%2 == 1 {
+		h = "0" + h
+	}
+	raw, err := hex.DecodeString(h)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex private key: %w", err)
+	}
+	size := (n.BitLen() + 7) / 8
+	if len(raw) > size {
+		return nil, fmt.Errorf("private key must be %d bytes", size)
+	}
+	return append(make([]byte, size-len(raw)), raw...), nil
+}
+
+// DER returns the private scalar of the SEC1 or PKCS#8 DER key of the curve
+func DER(der []byte, curve asn1.ObjectIdentifier, n *big.Int) ([]byte, error) {
+	var p pkcs8
+	if rest, err := asn1.Unmarshal(der, &p); err == nil && len(rest) == 0 {
+		if !p.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+			return nil, fmt.Errorf("unsupported PKCS#8 private key algorithm %s", p.Algo.Algorithm)
+		}
+		var paramsCurve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(p.Algo.Parameters.FullBytes, &paramsCurve); err != nil {
+			return nil, errors.New("invalid curve of the PKCS#8 private key")
+		}
+		if !paramsCurve.Equal(curve) {
+			return nil, fmt.Errorf("curve %s of the PKCS#8 private key isn't %s", paramsCurve, curve)
+		}
+		return sec1(p.PrivateKey, curve, n)
+	}
+	return sec1(der, curve, n)
+}
+
+func sec1(der []byte, curve asn1.ObjectIdentifier, n *big.Int) ([]byte, error) {
+	var key ecPrivateKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SEC1 or PKCS#8 private key: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after the DER private key")
+	}
+	if key.Version != 1 {
+		return nil, fmt.Errorf("unknown SEC1 private key version %d", key.Version)
+	}
+	// the curve is omitted in the SEC1 key wrapped by PKCS#8
+	if len(key.NamedCurveOID) > 0 && !key.NamedCurveOID.Equal(curve) {
+		return nil, fmt.Errorf("curve %s of the SEC1 private key isn't %s", key.NamedCurveOID, curve)
+	}
+	size := (n.BitLen() + 7) / 8
+	if len(key.PrivateKey) > size {
+		return nil, fmt.Errorf("private key must be %d bytes", size)
+	}
+	return append(make([]byte, size-len(key.PrivateKey)), key.PrivateKey...), nil
+}
+
+// PEM returns the private scalar of the first "EC PRIVATE KEY" or "PRIVATE KEY" block of the curve
+func PEM(data []byte, curve asn1.ObjectIdentifier, n *big.Int) ([]byte, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no EC private key in the PEM data")
+		}
+		switch block.Type {
+		case "EC PRIVATE KEY", "PRIVATE KEY":
+			if len(block.Headers) > 0 {
+				return nil, errors.New("encrypted PEM private key isn't supported")
+			}
+			return DER(block.Bytes, curve, n)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package eckey
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// the COSE_Key parameters of RFC 9053 and RFC 8812
+const (
+	COSEKeyTypeEC2     = 2
+	COSECurveP256      = 1
+	COSECurveSecp256k1 = 8
+	COSEAlgES256       = -7
+	COSEAlgES256K      = -47
+)
+
+type coseKey struct {
+	Kty int64  `cbor:"1,keyasint"`
+	Alg int64  `cbor:"3,keyasint,omitempty"`
+	Crv int64  `cbor:"-1,keyasint"`
+	X   []byte `cbor:"-2,keyasint"`
+	Y   []byte `cbor:"-3,keyasint"`
+}
+
+type subjectPublicKeyInfo struct {
+	Algo      pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// COSE returns x | y of the EC2 COSE_Key of the curve, and the alg is checked if it is present
+func COSE(data []byte, crv, alg int64) ([]byte, error) {
+	var key coseKey
+	if err := cbor.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("invalid COSE key: %w", err)
+	}
+	if key.Kty != COSEKeyTypeEC2 {
+		return nil, fmt.Errorf("COSE key type %d isn't EC2", key.Kty)
+	}
+	if key.Crv != crv {
+		return nil, fmt.Errorf("COSE key curve %d isn't %d", key.Crv, crv)
+	}
+	if key.Alg != 0 && key.Alg != alg {
+		return nil, fmt.Errorf("COSE key alg %d isn't %d", key.Alg, alg)
+	}
+	if len(key.X) != 32 || len(key.Y) != 32 {
+		return nil, errors.New("coordinates of the COSE key must be 32 bytes")
+	}
+	return append(append([]byte{}, key.X...), key.Y...), nil
+}
+
+// SPKI returns the SEC1 point of the SubjectPublicKeyInfo DER public key of the curve
+func SPKI(der []byte, curve asn1.ObjectIdentifier) ([]byte, error) {
+	var info subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPKI public key: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after the SPKI public key")
+	}
+	if !info.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, fmt.Errorf("unsupported SPKI public key algorithm %s", info.Algo.Algorithm)
+	}
+	var paramsCurve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &paramsCurve); err != nil {
+		return nil, errors.New("invalid curve of the SPKI public key")
+	}
+	if !paramsCurve.Equal(curve) {
+		return nil, fmt.Errorf("curve %s of the SPKI public key isn't %s", paramsCurve, curve)
+	}
+	return info.PublicKey.RightAlign(), nil
+}
+
+// JWK returns x | y of the EC JSON Web Key of the curve
+func JWK(data []byte, crv string) ([]byte, error) {
+	var key jwk
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("invalid JWK: %w", err)
+	}
+	if key.Kty != "EC" || key.Crv != crv {
+		return nil, fmt.Errorf("JWK %s %s isn't EC %s", key.Kty, key.Crv, crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(key.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x of the JWK: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(key.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y of the JWK: %w", err)
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("coordinates of the JWK must be 32 bytes")
+	}
+	return append(x, y...), nil
+}
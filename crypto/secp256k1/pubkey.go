@@ -0,0 +1,108 @@
+package secp256k1
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/internal/eckey"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/keccak"
+)
+
+// PublicKey is the secp256k1 public key without the private key, e.g. the Ethereum wallet key
+type PublicKey struct {
+	X, Y *big.Int
+}
+
+// NewPublicKey returns the public key of the point which must be on the secp256k1 curve
+func NewPublicKey(x, y *big.Int) (*PublicKey, error) {
+	if x == nil || y == nil || !secp256k1.S256().IsOnCurve(x, y) {
+		return nil, errors.New("public key isn't on the secp256k1 curve")
+	}
+	return &PublicKey{X: new(big.Int).Set(x), Y: new(big.Int).Set(y)}, nil
+}
+
+// ParsePublicKey parses the 64-byte x | y of JoyID, the 65-byte uncompressed or the 33-byte compressed SEC1 public key
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	var x, y *big.Int
+	switch {
+	case len(data) == 64:
+		x, y = new(big.Int).SetBytes(data[:32]), new(big.Int).SetBytes(data[32:])
+	case len(data) == 65 && data[0] == 0x04:
+		x, y = new(big.Int).SetBytes(data[1:33]), new(big.Int).SetBytes(data[33:])
+	case len(data) == 33 && (data[0] == 0x02 || data[0] == 0x03):
+		x, y = secp256k1.DecompressPubkey(data)
+	default:
+		return nil, errors.New("invalid secp256k1 public key length or prefix")
+	}
+	return NewPublicKey(x, y)
+}
+
+// ParsePublicKeyCOSE parses the EC2 secp256k1 COSE_Key of RFC 8812
+func ParsePublicKeyCOSE(data []byte) (*PublicKey, error) {
+	point, err := eckey.COSE(data, eckey.COSECurveSecp256k1, eckey.COSEAlgES256K)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(point)
+}
+
+// ParsePublicKeySPKI parses the SubjectPublicKeyInfo DER public key
+func ParsePublicKeySPKI(der []byte) (*PublicKey, error) {
+	point, err := eckey.SPKI(der, eckey.OIDSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(point)
+}
+
+// ParsePublicKeyJWK parses the EC secp256k1 JSON Web Key
+func ParsePublicKeyJWK(data []byte) (*PublicKey, error) {
+	point, err := eckey.JWK(data, "secp256k1")
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(point)
+}
+
+// Bytes returns the 64-byte x | y whose keccak160 is the pubkey hash of JoyID
+func (p *PublicKey) Bytes() []byte {
+	pubkey := make([]byte, 64)
+	p.X.FillBytes(pubkey[:32])
+	p.Y.FillBytes(pubkey[32:])
+	return pubkey
+}
+
+// Uncompressed returns the 65-byte uncompressed SEC1 public key
+func (p *PublicKey) Uncompressed() []byte {
+	return append([]byte{0x04}, p.Bytes()...)
+}
+
+// Compressed returns the 33-byte compressed SEC1 public key
+func (p *PublicKey) Compressed() []byte {
+	return secp256k1.CompressPubkey(p.X, p.Y)
+}
+
+// PubkeyHash returns keccak160 of the 64-byte public key
+func (p *PublicKey) PubkeyHash() []byte {
+	return keccak.Keccak160(p.Bytes())
+}
+
+func (p *PublicKey) Alg() alg.AlgIndex {
+	return alg.Secp256k1
+}
+
+func (p *PublicKey) ECDSA() *ecdsa.PublicKey {
+	return &ecdsa.PublicKey{Curve: secp256k1.S256(), X: p.X, Y: p.Y}
+}
+
+// PublicKey returns the public key of the private key, and it is nil if the key is invalid
+func (key *Key) PublicKey() *PublicKey {
+	pubkey, _ := key.Pubkey()
+	if pubkey == nil {
+		return nil
+	}
+	return &PublicKey{X: pubkey.X, Y: pubkey.Y}
+}
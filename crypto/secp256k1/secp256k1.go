@@ -3,12 +3,11 @@ package secp256k1
 import (
 	"crypto/ecdsa"
 	"crypto/rand"
-	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/internal/eckey"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/keccak"
-	"github.com/nervina-labs/joyid-sdk-go/utils"
 )
 
 type Key struct {
@@ -16,14 +15,66 @@ type Key struct {
 }
 
 func (k *Key) Bytes() []byte {
+	if !k.valid() {
+		return nil
+	}
 	return math.PaddedBigBytes(k.PrivateKey.D, k.PrivateKey.Params().BitSize/8)
 }
 
+// ImportKey imports the hex private key without returning the error, and the invalid key has no pubkey
+// and fails to sign.
+//
+// Deprecated: use ImportKeyHex to check the key.
 func ImportKey(privKey string) *Key {
-	privateKey := new(ecdsa.PrivateKey)
-	privateKey.Curve = secp256k1.S256()
-	privateKey.D, _ = new(big.Int).SetString(utils.Trim0x(privKey), 16)
-	return &Key{PrivateKey: privateKey}
+	key, err := ImportKeyHex(privKey)
+	if err != nil {
+		return &Key{PrivateKey: &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: secp256k1.S256()}}}
+	}
+	return key
+}
+
+// valid reports whether the key has the private scalar, e.g. it is false for the invalid key of ImportKey
+func (k *Key) valid() bool {
+	return k != nil && k.PrivateKey != nil && k.PrivateKey.D != nil
+}
+
+// ImportKeyHex imports the hex private key with or without 0x prefix
+func ImportKeyHex(privKey string) (*Key, error) {
+	raw, err := eckey.Hex(privKey, secp256k1.S256().N)
+	if err != nil {
+		return nil, err
+	}
+	return ImportKeyBytes(raw)
+}
+
+// ImportKeyBytes imports the 32-byte private key whose scalar must be in [1, n-1]
+func ImportKeyBytes(raw []byte) (*Key, error) {
+	d, err := eckey.Scalar(raw, secp256k1.S256().N)
+	if err != nil {
+		return nil, err
+	}
+	privateKey := &ecdsa.PrivateKey{D: d}
+	privateKey.PublicKey.Curve = secp256k1.S256()
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = privateKey.Curve.ScalarBaseMult(raw)
+	return &Key{PrivateKey: privateKey}, nil
+}
+
+// ImportKeyDER imports the SEC1 or PKCS#8 DER private key of secp256k1
+func ImportKeyDER(der []byte) (*Key, error) {
+	raw, err := eckey.DER(der, eckey.OIDSecp256k1, secp256k1.S256().N)
+	if err != nil {
+		return nil, err
+	}
+	return ImportKeyBytes(raw)
+}
+
+// ImportKeyPEM imports the "EC PRIVATE KEY" or "PRIVATE KEY" PEM private key of secp256k1
+func ImportKeyPEM(data []byte) (*Key, error) {
+	raw, err := eckey.PEM(data, eckey.OIDSecp256k1, secp256k1.S256().N)
+	if err != nil {
+		return nil, err
+	}
+	return ImportKeyBytes(raw)
 }
 
 func GenerateKey() (*Key, error) {
@@ -34,10 +85,16 @@ func GenerateKey() (*Key, error) {
 	return &Key{PrivateKey: privateKey}, nil
 }
 
+// Pubkey returns the public key and its 64-byte X | Y, and they are nil if the key is invalid
 func (key *Key) Pubkey() (*ecdsa.PublicKey, []byte) {
+	if !key.valid() {
+		return nil, nil
+	}
 	pubkey := key.PrivateKey.PublicKey
-	pubkey.Curve = secp256k1.S256()
-	pubkey.X, pubkey.Y = pubkey.Curve.ScalarBaseMult(key.PrivateKey.D.Bytes())
+	if pubkey.X == nil || pubkey.Y == nil {
+		pubkey.Curve = secp256k1.S256()
+		pubkey.X, pubkey.Y = pubkey.Curve.ScalarBaseMult(key.PrivateKey.D.Bytes())
+	}
 	pubkeyBytes := make([]byte, 64)
 	copy(pubkeyBytes[32-len(pubkey.X.Bytes()):32], pubkey.X.Bytes())
 	copy(pubkeyBytes[64-len(pubkey.Y.Bytes()):64], pubkey.Y.Bytes())
@@ -46,10 +103,16 @@ func (key *Key) Pubkey() (*ecdsa.PublicKey, []byte) {
 
 func (key *Key) PubkeyHash() []byte {
 	_, pubkey := key.Pubkey()
+	if pubkey == nil {
+		return nil
+	}
 	return keccak.Keccak160((pubkey))
 }
 
 func (key *Key) Sign(message []byte) []byte {
+	if !key.valid() {
+		return []byte{}
+	}
 	sig, err := secp256k1.Sign(message, key.Bytes())
 	if err != nil {
 		return []byte{}
@@ -64,3 +127,8 @@ func (key *Key) RecoverPubkey(message []byte, sig []byte) []byte {
 	}
 	return pubkey
 }
+
+// RecoverPubkey returns the 65-byte uncompressed pubkey from the message and the recoverable signature
+func RecoverPubkey(message []byte, sig []byte) ([]byte, error) {
+	return secp256k1.RecoverPubkey(message, sig)
+}
@@ -0,0 +1,106 @@
+package secp256r1
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// signDeterministic signs the digest with the nonce of RFC 6979 with HMAC-SHA256, so the same key and
+// digest always have the same signature
+func signDeterministic(privateKey *ecdsa.PrivateKey, digest []byte) (*big.Int, *big.Int, error) {
+	params := privateKey.Curve.Params()
+	n := params.N
+	d := privateKey.D
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, nil, errors.New("private key is out of range")
+	}
+	e := bitsToInt(digest, n)
+	// bits2octets(h1) of RFC 6979 section 2.3.4
+	z := new(big.Int).Mod(e, n)
+
+	kInv := new(big.Int)
+	r, s := new(big.Int), new(big.Int)
+	nonces := newNonceGenerator(intToOctets(d, n), intToOctets(z, n), n)
+	for {
+		k := nonces.next()
+		x, _ := privateKey.Curve.ScalarBaseMult(intToOctets(k, n))
+		r.Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k^-1 * (e + r * d) mod n
+		kInv.ModInverse(k, n)
+		s.Mul(r, d)
+		s.Add(s, e)
+		s.Mul(s, kInv)
+		s.Mod(s, n)
+		if s.Sign() != 0 {
+			return r, s, nil
+		}
+	}
+}
+
+// nonceGenerator is the HMAC_DRBG of RFC 6979 section 3.2
+type nonceGenerator struct {
+	k, v    []byte
+	n       *big.Int
+	started bool
+}
+
+func newNonceGenerator(x, h []byte, n *big.Int) *nonceGenerator {
+	g := &nonceGenerator{k: make([]byte, sha256.Size), v: make([]byte, sha256.Size), n: n}
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+	g.k = g.mac(g.v, []byte{0x00}, x, h)
+	g.v = g.mac(g.v)
+	g.k = g.mac(g.v, []byte{0x01}, x, h)
+	g.v = g.mac(g.v)
+	return g
+}
+
+// next returns the next candidate nonce in [1, n-1]
+func (g *nonceGenerator) next() *big.Int {
+	qlen := g.n.BitLen()
+	for {
+		if g.started {
+			g.k = g.mac(g.v, []byte{0x00})
+			g.v = g.mac(g.v)
+		}
+		g.started = true
+		var t []byte
+		for len(t)*8 < qlen {
+			g.v = g.mac(g.v)
+			t = append(t, g.v...)
+		}
+		k := bitsToInt(t, g.n)
+		if k.Sign() > 0 && k.Cmp(g.n) < 0 {
+			return k
+		}
+	}
+}
+
+func (g *nonceGenerator) mac(data ...[]byte) []byte {
+	h := hmac.New(sha256.New, g.k)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// bitsToInt is bits2int of RFC 6979 which keeps the leftmost bits of the bit length of n
+func bitsToInt(data []byte, n *big.Int) *big.Int {
+	x := new(big.Int).SetBytes(data)
+	if excess := len(data)*8 - n.BitLen(); excess > 0 {
+		x.Rsh(x, uint(excess))
+	}
+	return x
+}
+
+// intToOctets is int2octets of RFC 6979 which pads x to the byte length of n
+func intToOctets(x, n *big.Int) []byte {
+	return x.FillBytes(make([]byte, (n.BitLen()+7)/8))
+}
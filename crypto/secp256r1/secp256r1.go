@@ -4,26 +4,82 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/asn1"
+	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/math"
-	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/internal/eckey"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/blake2b"
 )
 
 type Key struct {
 	PrivateKey *ecdsa.PrivateKey
+	// Deterministic signs with the nonce of RFC 6979 instead of the random nonce
+	Deterministic bool
 }
 
 func (k *Key) Bytes() []byte {
+	if !k.valid() {
+		return nil
+	}
 	return math.PaddedBigBytes(k.PrivateKey.D, k.PrivateKey.Params().BitSize/8)
 }
 
+// ImportKey imports the hex private key without returning the error, and the invalid key has no pubkey
+// and fails to sign.
+//
+// Deprecated: use ImportKeyHex to check the key.
 func ImportKey(privKey string) *Key {
-	privateKey := new(ecdsa.PrivateKey)
-	privateKey.Curve = elliptic.P256()
-	privateKey.D, _ = new(big.Int).SetString(utils.Trim0x(privKey), 16)
-	return &Key{PrivateKey: privateKey}
+	key, err := ImportKeyHex(privKey)
+	if err != nil {
+		return &Key{PrivateKey: &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: elliptic.P256()}}}
+	}
+	return key
+}
+
+// valid reports whether the key has the private scalar, e.g. it is false for the invalid key of ImportKey
+func (k *Key) valid() bool {
+	return k != nil && k.PrivateKey != nil && k.PrivateKey.D != nil
+}
+
+// ImportKeyHex imports the hex private key with or without 0x prefix
+func ImportKeyHex(privKey string) (*Key, error) {
+	raw, err := eckey.Hex(privKey, elliptic.P256().Params().N)
+	if err != nil {
+		return nil, err
+	}
+	return ImportKeyBytes(raw)
+}
+
+// ImportKeyBytes imports the 32-byte private key whose scalar must be in [1, n-1]
+func ImportKeyBytes(raw []byte) (*Key, error) {
+	d, err := eckey.Scalar(raw, elliptic.P256().Params().N)
+	if err != nil {
+		return nil, err
+	}
+	privateKey := &ecdsa.PrivateKey{D: d}
+	privateKey.PublicKey.Curve = elliptic.P256()
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = privateKey.Curve.ScalarBaseMult(raw)
+	return &Key{PrivateKey: privateKey}, nil
+}
+
+// ImportKeyDER imports the SEC1 or PKCS#8 DER private key of secp256r1
+func ImportKeyDER(der []byte) (*Key, error) {
+	raw, err := eckey.DER(der, eckey.OIDSecp256r1, elliptic.P256().Params().N)
+	if err != nil {
+		return nil, err
+	}
+	return ImportKeyBytes(raw)
+}
+
+// ImportKeyPEM imports the "EC PRIVATE KEY" or "PRIVATE KEY" PEM private key of secp256r1
+func ImportKeyPEM(data []byte) (*Key, error) {
+	raw, err := eckey.PEM(data, eckey.OIDSecp256r1, elliptic.P256().Params().N)
+	if err != nil {
+		return nil, err
+	}
+	return ImportKeyBytes(raw)
 }
 
 func GenerateKey() (*Key, error) {
@@ -34,10 +90,16 @@ func GenerateKey() (*Key, error) {
 	return &Key{PrivateKey: privateKey}, nil
 }
 
+// Pubkey returns the public key and its 64-byte X | Y, and they are nil if the key is invalid
 func (key *Key) Pubkey() (*ecdsa.PublicKey, []byte) {
+	if !key.valid() {
+		return nil, nil
+	}
 	pubkey := key.PrivateKey.PublicKey
-	pubkey.Curve = elliptic.P256()
-	pubkey.X, pubkey.Y = pubkey.Curve.ScalarBaseMult(key.PrivateKey.D.Bytes())
+	if pubkey.X == nil || pubkey.Y == nil {
+		pubkey.Curve = elliptic.P256()
+		pubkey.X, pubkey.Y = pubkey.Curve.ScalarBaseMult(key.PrivateKey.D.Bytes())
+	}
 	pubkeyBytes := make([]byte, 64)
 	copy(pubkeyBytes[32-len(pubkey.X.Bytes()):32], pubkey.X.Bytes())
 	copy(pubkeyBytes[64-len(pubkey.Y.Bytes()):64], pubkey.Y.Bytes())
@@ -46,19 +108,30 @@ func (key *Key) Pubkey() (*ecdsa.PublicKey, []byte) {
 
 func (key *Key) PubkeyHash() []byte {
 	_, pubkey := key.Pubkey()
+	if pubkey == nil {
+		return nil
+	}
 	return blake2b.Blake160(pubkey)
 }
 
+// Sign returns the 64-byte r|s signature of the digest with low S
 func (key *Key) Sign(message []byte) []byte {
-	r, s, err := ecdsa.Sign(rand.Reader, key.PrivateKey, message)
+	if !key.valid() {
+		return []byte{}
+	}
+	var r, s *big.Int
+	var err error
+	if key.Deterministic {
+		r, s, err = signDeterministic(key.PrivateKey, message)
+	} else {
+		r, s, err = ecdsa.Sign(rand.Reader, key.PrivateKey, message)
+	}
 	if err != nil {
 		return []byte{}
 	}
-	rBytes := r.Bytes()
-	sBytes := s.Bytes()
 	sigBytes := make([]byte, 64)
-	copy(sigBytes[32-len(rBytes):32], rBytes)
-	copy(sigBytes[64-len(sBytes):64], sBytes)
+	r.FillBytes(sigBytes[:32])
+	NormalizeS(s).FillBytes(sigBytes[32:])
 	return sigBytes
 }
 
@@ -70,3 +143,48 @@ func (key *Key) VerifySignature(message []byte) bool {
 	pubkey, _ := key.Pubkey()
 	return ecdsa.Verify(pubkey, message, r, s)
 }
+
+// NormalizeS returns n - s if s is greater than n / 2 to make the signature not malleable
+func NormalizeS(s *big.Int) *big.Int {
+	n := elliptic.P256().Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		return new(big.Int).Sub(n, s)
+	}
+	return s
+}
+
+// ParseDERSignature converts the ASN.1 DER signature of WebAuthn to the 64-byte r|s signature with low S
+func ParseDERSignature(der []byte) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after DER signature")
+	}
+	n := elliptic.P256().Params().N
+	if sig.R.Sign() <= 0 || sig.S.Sign() <= 0 || sig.R.Cmp(n) >= 0 || sig.S.Cmp(n) >= 0 {
+		return nil, errors.New("invalid secp256r1 signature")
+	}
+	sigBytes := make([]byte, 64)
+	sig.R.FillBytes(sigBytes[:32])
+	NormalizeS(sig.S).FillBytes(sigBytes[32:])
+	return sigBytes, nil
+}
+
+// Verify checks the 64-byte r|s signature of the digest with the 64-byte uncompressed pubkey
+func Verify(pubkey []byte, digest []byte, sig []byte) bool {
+	if len(pubkey) != 64 || len(sig) != 64 {
+		return false
+	}
+	publicKey := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(pubkey[:32]),
+		Y:     new(big.Int).SetBytes(pubkey[32:]),
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	return ecdsa.Verify(publicKey, digest, r, s)
+}
@@ -1,8 +1,20 @@
 package secp256r1
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/nervina-labs/joyid-sdk-go/utils"
 )
 
@@ -80,3 +92,166 @@ func TestVerifySignature2(t *testing.T) {
 		t.Errorf("VerifiSignature() = %t, want %t", got, want)
 	}
 }
+
+func TestParseDERSignature(t *testing.T) {
+	// r = 1, s = n - 1 which is normalized to 1
+	der, _ := utils.HexToBytes("0x3026020101022100ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632550")
+	got, err := ParseDERSignature(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001"
+	if utils.BytesTo0xHex(got) != want {
+		t.Errorf("ParseDERSignature() = %x, want %s", got, want)
+	}
+
+	if _, err := ParseDERSignature([]byte{0x30, 0x00, 0x01}); err == nil {
+		t.Errorf("ParseDERSignature() should fail with invalid DER")
+	}
+}
+
+func TestImportKeyFormats(t *testing.T) {
+	want := "0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761"
+	raw, _ := utils.HexToBytes(want)
+	key, err := ImportKeyHex(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, _ := x509.MarshalECPrivateKey(key.PrivateKey)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	importers := map[string]func() (*Key, error){
+		"bytes":      func() (*Key, error) { return ImportKeyBytes(raw) },
+		"SEC1 DER":   func() (*Key, error) { return ImportKeyDER(sec1) },
+		"PKCS#8 DER": func() (*Key, error) { return ImportKeyDER(pkcs8) },
+		"SEC1 PEM": func() (*Key, error) {
+			return ImportKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}))
+		},
+		"PKCS#8 PEM": func() (*Key, error) {
+			return ImportKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+		},
+	}
+	for name, importKey := range importers {
+		got, err := importKey()
+		if err != nil {
+			t.Errorf("%s: import error = %v", name, err)
+			continue
+		}
+		if utils.BytesTo0xHex(got.Bytes()) != want || !got.PrivateKey.PublicKey.Equal(&key.PrivateKey.PublicKey) {
+			t.Errorf("%s: import = %s, want %s", name, utils.BytesTo0xHex(got.Bytes()), want)
+		}
+	}
+}
+
+func TestImportInvalidKey(t *testing.T) {
+	invalidKeys := []string{
+		"",
+		"0xzz",
+		"0x0000000000000000000000000000000000000000000000000000000000000000",
+		// the order of secp256r1
+		"0xffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551",
+	}
+	for _, privKey := range invalidKeys {
+		if _, err := ImportKeyHex(privKey); err == nil {
+			t.Errorf("ImportKeyHex(%q) should fail", privKey)
+		}
+	}
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(p384Key)
+	if _, err := ImportKeyDER(der); err == nil {
+		t.Errorf("ImportKeyDER() should fail with P-384 key")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key := ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	pubkey := key.PublicKey()
+	x, y := pubkey.Bytes()[:32], pubkey.Bytes()[32:]
+	cose, _ := cbor.Marshal(map[int]interface{}{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+	spki, _ := x509.MarshalPKIXPublicKey(pubkey.ECDSA())
+	jwk := fmt.Sprintf(`{"kty":"EC","crv":"P-256","x":"%s","y":"%s"}`, base64.RawURLEncoding.EncodeToString(x), base64.RawURLEncoding.EncodeToString(y))
+	parsers := map[string]func() (*PublicKey, error){
+		"raw":          func() (*PublicKey, error) { return ParsePublicKey(pubkey.Bytes()) },
+		"uncompressed": func() (*PublicKey, error) { return ParsePublicKey(pubkey.Uncompressed()) },
+		"compressed":   func() (*PublicKey, error) { return ParsePublicKey(pubkey.Compressed()) },
+		"COSE":         func() (*PublicKey, error) { return ParsePublicKeyCOSE(cose) },
+		"SPKI":         func() (*PublicKey, error) { return ParsePublicKeySPKI(spki) },
+		"JWK":          func() (*PublicKey, error) { return ParsePublicKeyJWK([]byte(jwk)) },
+	}
+	for name, parse := range parsers {
+		got, err := parse()
+		if err != nil {
+			t.Errorf("%s: parse error = %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got.PubkeyHash(), key.PubkeyHash()) {
+			t.Errorf("%s: PubkeyHash() = %x, want %x", name, got.PubkeyHash(), key.PubkeyHash())
+		}
+	}
+
+	invalidCoses := map[string]map[int]interface{}{
+		"OKP key type":    {1: 1, -1: 1, -2: x, -3: y},
+		"secp256k1 curve": {1: 2, 3: -7, -1: 8, -2: x, -3: y},
+		"RS256 alg":       {1: 2, 3: -257, -1: 1, -2: x, -3: y},
+		"short x":         {1: 2, 3: -7, -1: 1, -2: x[1:], -3: y},
+	}
+	for name, invalid := range invalidCoses {
+		data, _ := cbor.Marshal(invalid)
+		if _, err := ParsePublicKeyCOSE(data); err == nil {
+			t.Errorf("ParsePublicKeyCOSE() should fail with %s", name)
+		}
+	}
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	p384Spki, _ := x509.MarshalPKIXPublicKey(&p384Key.PublicKey)
+	if _, err := ParsePublicKeySPKI(p384Spki); err == nil {
+		t.Errorf("ParsePublicKeySPKI() should fail with P-384 key")
+	}
+	if _, err := ParsePublicKey(append([]byte{0x05}, pubkey.Bytes()...)); err == nil {
+		t.Errorf("ParsePublicKey() should fail with invalid prefix")
+	}
+}
+
+func TestPublicKeyWithInvalidKey(t *testing.T) {
+	if pubkey := ImportKey("zz").PublicKey(); pubkey != nil {
+		t.Errorf("PublicKey() = %+v, want nil with invalid key", pubkey)
+	}
+}
+
+func TestSignDeterministic(t *testing.T) {
+	// RFC 6979 A.2.5 with P-256 and SHA-256, and s of "sample" is normalized to low S
+	key, _ := ImportKeyHex("0xc9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	key.Deterministic = true
+	testcases := []struct {
+		message, wantR, wantS string
+	}{
+		{"sample", "efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716", "f7cb1c942d657c41d436c7a1b6e29f65f3e900dbb9aff4064dc4ab2f843acda8"},
+		{"test", "f1abb023518351cd71d881567b1ea663ed3efcf6c5132b354f28d3b0b7d38367", "019f4113742a2b14bd25926b49c649155f267e60d3814b4c0cc84250e46f0083"},
+	}
+	for _, tc := range testcases {
+		digest := sha256.Sum256([]byte(tc.message))
+		sig := key.Sign(digest[:])
+		s, _ := new(big.Int).SetString(tc.wantS, 16)
+		want := tc.wantR + hex.EncodeToString(NormalizeS(s).FillBytes(make([]byte, 32)))
+		if got := hex.EncodeToString(sig); got != want {
+			t.Errorf("Sign(%q) = %s, want %s", tc.message, got, want)
+		}
+		if got := hex.EncodeToString(key.Sign(digest[:])); got != want {
+			t.Errorf("Sign(%q) isn't deterministic, got %s", tc.message, got)
+		}
+	}
+}
+
+func TestSignLowS(t *testing.T) {
+	key := ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	halfN := new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+	_, pubkey := key.Pubkey()
+	for i := 0; i < 16; i++ {
+		digest := sha256.Sum256([]byte{byte(i)})
+		sig := key.Sign(digest[:])
+		if new(big.Int).SetBytes(sig[32:]).Cmp(halfN) > 0 {
+			t.Errorf("Sign() = %x, want low S", sig)
+		}
+		if !Verify(pubkey, digest[:], sig) {
+			t.Errorf("Verify() = false, want true")
+		}
+	}
+}
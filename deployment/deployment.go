@@ -0,0 +1,181 @@
+package deployment
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// Script is the deployment of the on-chain script and the cell dep of its code
+type Script struct {
+	CodeHash types.Hash           `json:"code_hash" toml:"code_hash"`
+	HashType types.ScriptHashType `json:"hash_type" toml:"hash_type"`
+	TxHash   types.Hash           `json:"tx_hash" toml:"tx_hash"`
+	Index    uint32               `json:"index" toml:"index"`
+	DepType  types.DepType        `json:"dep_type" toml:"dep_type"`
+}
+
+// Deployment is the JoyID lock and the CoTA type deployed on the chain, and Network is the address
+// prefix of the chain where devnets use the testnet one
+type Deployment struct {
+	Name      string
+	Network   types.Network
+	JoyIDLock Script
+	CotaType  Script
+}
+
+var (
+	Mainnet = &Deployment{
+		Name:    "mainnet",
+		Network: types.NetworkMain,
+		JoyIDLock: Script{
+			CodeHash: types.HexToHash("0xd00c84f0ec8fd441c38bc3f87a371f547190f2fcff88e642bc5bf54b9e318323"),
+			HashType: types.HashTypeType,
+			TxHash:   types.HexToHash("0xf05188e5f3a6767fc4687faf45ba5f1a6e25d3ada6129dae8722cb282f262493"),
+			DepType:  types.DepTypeDepGroup,
+		},
+		CotaType: Script{
+			CodeHash: types.HexToHash("0x1122a4fb54697cf2e6e3a96c9d80fd398a936559b90954c6e88eb7ba0cf652df"),
+			HashType: types.HashTypeType,
+			TxHash:   types.HexToHash("0x875db3381ebe7a730676c110e1c0d78ae1bdd0c11beacb7db4db08e368c2cd95"),
+			DepType:  types.DepTypeDepGroup,
+		},
+	}
+
+	Testnet = &Deployment{
+		Name:    "testnet",
+		Network: types.NetworkTest,
+		JoyIDLock: Script{
+			CodeHash: types.HexToHash("0x07595bf3a836bdb7f93a91a66cb2c8cbaf0d807e8adecddc9d910aac6f0a5d0f"),
+			HashType: types.HashTypeType,
+			TxHash:   types.HexToHash("0x68777db22145ce8e55014cbfd0d52e7357068451ea539ac7df952a36a9696f02"),
+			DepType:  types.DepTypeDepGroup,
+		},
+		CotaType: Script{
+			CodeHash: types.HexToHash("0x89cd8003a0eaf8e65e0c31525b7d1d5c1becefd2ea75bb4cff87810ae37764d8"),
+			HashType: types.HashTypeType,
+			TxHash:   types.HexToHash("0x636a786001f87cb615acfcf408be0f9a1f077001f0bbc75ca54eadfe7e221713"),
+			DepType:  types.DepTypeDepGroup,
+		},
+	}
+)
+
+var (
+	mu sync.RWMutex
+	// deployments are the presets and the custom deployments by name
+	deployments = map[string]*Deployment{Mainnet.Name: Mainnet, Testnet.Name: Testnet}
+	// current are the deployments used by the SDK for the networks
+	current = map[types.Network]*Deployment{types.NetworkMain: Mainnet, types.NetworkTest: Testnet}
+)
+
+// CellDep returns the cell dep of the script code
+func (s *Script) CellDep() *types.CellDep {
+	return &types.CellDep{
+		OutPoint: &types.OutPoint{TxHash: s.TxHash, Index: s.Index},
+		DepType:  s.DepType,
+	}
+}
+
+// Script returns the script of the deployment with the args
+func (s *Script) Script(args []byte) *types.Script {
+	return &types.Script{CodeHash: s.CodeHash, HashType: s.HashType, Args: args}
+}
+
+// IsMatched returns true if the script is of the deployment
+func (s *Script) IsMatched(script *types.Script) bool {
+	return script != nil && script.CodeHash == s.CodeHash && script.HashType == s.HashType
+}
+
+func (s *Script) validate(name string) error {
+	if s.CodeHash == (types.Hash{}) {
+		return fmt.Errorf("code hash of %s cannot be empty", name)
+	}
+	switch s.HashType {
+	case types.HashTypeData, types.HashTypeData1, types.HashTypeType:
+	default:
+		return fmt.Errorf("invalid hash type %q of %s", s.HashType, name)
+	}
+	if s.TxHash == (types.Hash{}) {
+		return fmt.Errorf("tx hash of %s cannot be empty", name)
+	}
+	if s.DepType != types.DepTypeCode && s.DepType != types.DepTypeDepGroup {
+		return fmt.Errorf("invalid dep type %q of %s", s.DepType, name)
+	}
+	return nil
+}
+
+// Validate checks the scripts of the deployment
+func (d *Deployment) Validate() error {
+	if d.Name == "" {
+		return errors.New("name of the deployment cannot be empty")
+	}
+	if d.Network != types.NetworkMain && d.Network != types.NetworkTest {
+		return fmt.Errorf("invalid network %d of the deployment %s", d.Network, d.Name)
+	}
+	if err := d.JoyIDLock.validate("JoyID lock"); err != nil {
+		return fmt.Errorf("deployment %s: %w", d.Name, err)
+	}
+	if err := d.CotaType.validate("CoTA type"); err != nil {
+		return fmt.Errorf("deployment %s: %w", d.Name, err)
+	}
+	return nil
+}
+
+// Register adds the custom deployment, e.g. the devnet, which can be found by Lookup
+func Register(d *Deployment) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	deployments[d.Name] = d
+	return nil
+}
+
+// Lookup returns the preset or the registered deployment by name
+func Lookup(name string) (*Deployment, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	d, ok := deployments[name]
+	if !ok {
+		return nil, fmt.Errorf("deployment %s doesn't exist", name)
+	}
+	return d, nil
+}
+
+// Use registers the deployment and makes it the one used by the SDK for its network, so the devnet
+// deployment with the testnet prefix replaces the testnet preset
+func Use(d *Deployment) error {
+	if err := Register(d); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current[d.Network] = d
+	return nil
+}
+
+// Get returns the deployment used by the SDK for the network, which is the preset unless it is
+// replaced by Use, and unknown networks fall back to the testnet
+func Get(network types.Network) *Deployment {
+	mu.RLock()
+	defer mu.RUnlock()
+	if d, ok := current[network]; ok {
+		return d
+	}
+	return current[types.NetworkTest]
+}
+
+// IsJoyIDLock returns true if the script is the JoyID lock of any known deployment
+func IsJoyIDLock(script *types.Script) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, d := range deployments {
+		if d.JoyIDLock.IsMatched(script) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package deployment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const devnetJSON = `{
+	"devnet": {
+		"network": "testnet",
+		"joyid_lock": {
+			"code_hash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+			"hash_type": "type",
+			"tx_hash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+			"index": 1,
+			"dep_type": "dep_group"
+		},
+		"cota_type": {
+			"code_hash": "0x3333333333333333333333333333333333333333333333333333333333333333",
+			"hash_type": "data1",
+			"tx_hash": "0x4444444444444444444444444444444444444444444444444444444444444444",
+			"index": 2,
+			"dep_type": "code"
+		}
+	}
+}`
+
+const devnetTOML = `
+[devnet]
+network = "testnet"
+
+[devnet.joyid_lock]
+code_hash = "0x1111111111111111111111111111111111111111111111111111111111111111"
+hash_type = "type"
+tx_hash = "0x2222222222222222222222222222222222222222222222222222222222222222"
+index = 1
+dep_type = "dep_group"
+
+[devnet.cota_type]
+code_hash = "0x3333333333333333333333333333333333333333333333333333333333333333"
+hash_type = "data1"
+tx_hash = "0x4444444444444444444444444444444444444444444444444444444444444444"
+index = 2
+dep_type = "code"
+`
+
+func TestLoad(t *testing.T) {
+	want := Deployment{
+		Name:    "devnet",
+		Network: types.NetworkTest,
+		JoyIDLock: Script{
+			CodeHash: types.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111"),
+			HashType: types.HashTypeType,
+			TxHash:   types.HexToHash("0x2222222222222222222222222222222222222222222222222222222222222222"),
+			Index:    1,
+			DepType:  types.DepTypeDepGroup,
+		},
+		CotaType: Script{
+			CodeHash: types.HexToHash("0x3333333333333333333333333333333333333333333333333333333333333333"),
+			HashType: types.HashTypeData1,
+			TxHash:   types.HexToHash("0x4444444444444444444444444444444444444444444444444444444444444444"),
+			Index:    2,
+			DepType:  types.DepTypeCode,
+		},
+	}
+	dir := t.TempDir()
+	for name, data := range map[string]string{"devnet.json": devnetJSON, "devnet.toml": devnetTOML} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) error = %v", name, err)
+		}
+		if len(got) != 1 || *got[0] != want {
+			t.Errorf("LoadFile(%s) = %+v, want %+v", name, got, want)
+		}
+		if d, err := Lookup("devnet"); err != nil || *d != want {
+			t.Errorf("Lookup() = %+v, want %+v", d, want)
+		}
+	}
+
+	if _, err := LoadJSON([]byte(`{"broken": {"network": "testnet"}}`)); err == nil {
+		t.Errorf("LoadJSON() should fail without scripts")
+	}
+	if _, err := Lookup("broken"); err == nil {
+		t.Errorf("invalid deployment shouldn't be registered")
+	}
+	if _, err := LoadTOML([]byte(devnetTOML[strings.Index(devnetTOML, "[devnet.joyid_lock]"):])); err == nil {
+		t.Errorf("LoadTOML() should fail without the network")
+	}
+	if _, err := LoadFile(filepath.Join(dir, "devnet.yaml")); err == nil {
+		t.Errorf("LoadFile() should fail with unsupported file")
+	}
+}
+
+func TestUse(t *testing.T) {
+	if Get(types.NetworkMain) != Mainnet || Get(types.NetworkTest) != Testnet {
+		t.Fatalf("Get() should return the presets by default")
+	}
+	if Mainnet.JoyIDLock.CodeHash == Testnet.JoyIDLock.CodeHash {
+		t.Errorf("mainnet and testnet JoyID lock code hashes should be different")
+	}
+	devnet := *Testnet
+	devnet.Name = "local"
+	devnet.JoyIDLock.CodeHash = types.HexToHash("0x5555555555555555555555555555555555555555555555555555555555555555")
+	if err := Use(&devnet); err != nil {
+		t.Fatal(err)
+	}
+	defer Use(Testnet)
+
+	if got := Get(types.NetworkTest); got != &devnet {
+		t.Errorf("Get() = %s, want %s", got.Name, devnet.Name)
+	}
+	if Get(types.NetworkMain) != Mainnet {
+		t.Errorf("Use() shouldn't replace the deployment of other network")
+	}
+	lock := devnet.JoyIDLock.Script([]byte{0x00, 0x01})
+	if !IsJoyIDLock(lock) || !IsJoyIDLock(Mainnet.JoyIDLock.Script(nil)) {
+		t.Errorf("IsJoyIDLock() should match the JoyID locks of all deployments")
+	}
+	if IsJoyIDLock(devnet.CotaType.Script(nil)) {
+		t.Errorf("IsJoyIDLock() should be false for the CoTA type")
+	}
+	if cellDep := devnet.JoyIDLock.CellDep(); cellDep.OutPoint.TxHash != devnet.JoyIDLock.TxHash || cellDep.DepType != types.DepTypeDepGroup {
+		t.Errorf("CellDep() = %+v", cellDep)
+	}
+}
@@ -0,0 +1,112 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// entry is the deployment in the config file, which is keyed by the name of the deployment:
+//
+//	[devnet]
+//	network = "testnet"
+//	[devnet.joyid_lock]
+//	code_hash = "0x..."
+//	hash_type = "type"
+//	tx_hash = "0x..."
+//	index = 0
+//	dep_type = "dep_group"
+//	[devnet.cota_type]
+//	...
+type entry struct {
+	Network   string `json:"network" toml:"network"`
+	JoyIDLock Script `json:"joyid_lock" toml:"joyid_lock"`
+	CotaType  Script `json:"cota_type" toml:"cota_type"`
+}
+
+// LoadJSON parses and registers the deployments of the JSON config
+func LoadJSON(data []byte) ([]*Deployment, error) {
+	var entries map[string]entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid JSON deployments: %w", err)
+	}
+	return register(entries)
+}
+
+// LoadTOML parses and registers the deployments of the TOML config
+func LoadTOML(data []byte) ([]*Deployment, error) {
+	var entries map[string]entry
+	if err := toml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid TOML deployments: %w", err)
+	}
+	return register(entries)
+}
+
+// LoadFile parses and registers the deployments of the .json or .toml config file
+func LoadFile(path string) ([]*Deployment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return LoadJSON(data)
+	case ".toml":
+		return LoadTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported deployment file %s", path)
+	}
+}
+
+func register(entries map[string]entry) ([]*Deployment, error) {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// all deployments are validated before any of them is registered
+	var result []*Deployment
+	for _, name := range names {
+		d, err := entries[name].deployment(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.Validate(); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	for _, d := range result {
+		if err := Register(d); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (e entry) deployment(name string) (*Deployment, error) {
+	var network types.Network
+	switch strings.ToLower(e.Network) {
+	case "":
+		return nil, fmt.Errorf("network of the deployment %s cannot be empty", name)
+	case "mainnet", "main":
+		network = types.NetworkMain
+	case "testnet", "test", "devnet", "dev":
+		network = types.NetworkTest
+	default:
+		return nil, fmt.Errorf("invalid network %q of the deployment %s", e.Network, name)
+	}
+	return &Deployment{
+		Name:      name,
+		Network:   network,
+		JoyIDLock: e.JoyIDLock,
+		CotaType:  e.CotaType,
+	}, nil
+}
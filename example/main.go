@@ -8,20 +8,28 @@ import (
 	"github.com/nervosnetwork/ckb-sdk-go/v2/collector"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/collector/builder"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
 
 	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/cota"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
 	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/subkey"
 	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/webauthn"
 )
 
 const (
 	testnetCkbNodeUrl    = "https://testnet.ckb.dev/rpc"
 	testnetCkbIndexerUrl = "https://testnet.ckb.dev/indexer"
 	testnetAggregatorUrl = "https://cota.nervina.dev/aggregator"
+
+	webAuthnOrigin      = "http://localhost:8000"
+	webAuthnAuthDataLen = 37
 )
 
 func NativeTransferWithR1() error {
@@ -45,28 +53,34 @@ func NativeTransferWithR1() error {
 		return err
 	}
 	builder.AddChangeOutputByAddress(sender)
-	builder.AddCellDep(utils.JoyIDLockCellDep(network, nil))
-	txWithGroups, err := builder.Build()
+	builder.Register(handler.NewJoyIDScriptHandler(network))
+	// The exact WebAuthn message length makes the fee of the secp256r1 witness accurate
+	txWithGroups, err := builder.Build(&handler.JoyIDUnlockContext{
+		Mode:           signer.NativeUnlock,
+		WebAuthnMsgLen: signer.WebAuthnMsgLen(webAuthnAuthDataLen, webAuthnOrigin, false),
+	})
 	if err != nil {
 		return err
 	}
 
 	// sign transaction
 	tx := txWithGroups.TxView
-	fmt.Println(len(tx.Inputs))
-	witnessArgs := types.WitnessArgs{
-		Lock: []byte{},
-	}
-	tx.Witnesses = [][]byte{witnessArgs.Serialize()}
-	webAuthnMsg, err := generateWebAuthnMsg(tx)
+	senderAddr, err := address.Decode(sender)
 	if err != nil {
 		return err
 	}
+	group := signer.FindLockScriptGroup(txWithGroups.ScriptGroups, senderAddr.Script)
 	algKey := signer.AlgPrivKey{
 		PrivKey: senderPrivKey,
 		Alg:     alg.Secp256r1,
 	}
-	signer.SignNativeUnlockTx(tx, algKey, webAuthnMsg)
+	authenticatorSigner, err := newAuthenticatorSigner(algKey.PrivKey)
+	if err != nil {
+		return err
+	}
+	if err := signer.SignNativeUnlockTx(tx, group, authenticatorSigner); err != nil {
+		return err
+	}
 
 	// send transaction
 	hash, err := client.SendTransaction(context.Background(), tx)
@@ -98,7 +112,7 @@ func NativeTransferWithK1() error {
 		return err
 	}
 	builder.AddChangeOutputByAddress(sender)
-	builder.AddCellDep(utils.JoyIDLockCellDep(network, nil))
+	builder.Register(handler.NewJoyIDScriptHandler(network))
 	txWithGroups, err := builder.Build()
 	if err != nil {
 		return err
@@ -106,15 +120,18 @@ func NativeTransferWithK1() error {
 
 	// sign transaction
 	tx := txWithGroups.TxView
-	witnessArgs := types.WitnessArgs{
-		Lock: []byte{},
+	senderAddr, err := address.Decode(sender)
+	if err != nil {
+		return err
 	}
-	tx.Witnesses = [][]byte{witnessArgs.Serialize()}
+	group := signer.FindLockScriptGroup(txWithGroups.ScriptGroups, senderAddr.Script)
 	algKey := signer.AlgPrivKey{
 		PrivKey: senderPrivKey,
 		Alg:     alg.Secp256k1,
 	}
-	signer.SignNativeUnlockTx(tx, algKey, nil)
+	if err := signer.SignNativeUnlockTx(tx, group, algKey.Signer(nil)); err != nil {
+		return err
+	}
 
 	// send transaction
 	hash, err := client.SendTransaction(context.Background(), tx)
@@ -151,39 +168,35 @@ func SubkeyTransferWithR1() error {
 	if err != nil {
 		return err
 	}
-	cotaCellDep, err := utils.CotaCellDep(testnetCkbIndexerUrl, senderAddr)
-	if err != nil {
-		return err
+	algKey := signer.AlgPrivKey{
+		PrivKey: senderSubkeyPrivKey,
+		Alg:     alg.Secp256r1,
 	}
-	builder.AddCellDep(cotaCellDep)
-	builder.AddCellDep(utils.JoyIDLockCellDep(network, nil))
-	txWithGroups, err := builder.Build()
+	// The handler adds CoTA cell dep and subkey unlock smt to WitnessArgs.OutputType
+	builder.Register(handler.NewJoyIDScriptHandler(network))
+	txWithGroups, err := builder.Build(&handler.JoyIDUnlockContext{
+		Mode:             signer.SubkeyUnlock,
+		Alg:              algKey.Alg,
+		WebAuthnMsgLen:   signer.WebAuthnMsgLen(webAuthnAuthDataLen, webAuthnOrigin, false),
+		SubkeyPubkeyHash: algKey.PubkeyHash(),
+		AggregatorUrl:    testnetAggregatorUrl,
+		IndexerUrl:       testnetCkbIndexerUrl,
+	})
 	if err != nil {
 		return err
 	}
 
-	// Init WitnessArgs
 	tx := txWithGroups.TxView
-	witnessArgs := types.WitnessArgs{
-		Lock: []byte{},
-	}
-	tx.Witnesses = [][]byte{witnessArgs.Serialize()}
+	group := signer.FindLockScriptGroup(txWithGroups.ScriptGroups, senderAddr.Script)
 
-	// Add subkey unlock smt to WitnessArgs.Output
-	algKey := signer.AlgPrivKey{
-		PrivKey: senderSubkeyPrivKey,
-		Alg:     alg.Secp256r1,
-	}
-	signer.BuildOutputTypeWithSubkeySmt(tx, algKey, senderAddr, testnetAggregatorUrl)
-
-	// Build webAuthn message
-	webAuthnMsg, err := generateWebAuthnMsg(tx)
+	// Sign transaction
+	authenticatorSigner, err := newAuthenticatorSigner(algKey.PrivKey)
 	if err != nil {
 		return err
 	}
-
-	// Sign transaction
-	signer.SignSubkeyUnlockTx(tx, algKey, webAuthnMsg)
+	if err := signer.SignSubkeyUnlockTx(tx, group, authenticatorSigner); err != nil {
+		return err
+	}
 
 	// send transaction
 	hash, err := client.SendTransaction(context.Background(), tx)
@@ -220,33 +233,30 @@ func SubkeyTransferWithK1() error {
 	if err != nil {
 		return err
 	}
-	cotaCellDep, err := utils.CotaCellDep(testnetCkbIndexerUrl, senderAddr)
-	if err != nil {
-		return err
+	algKey := signer.AlgPrivKey{
+		PrivKey: senderSubkeyPrivKey,
+		Alg:     alg.Secp256k1,
 	}
-	builder.AddCellDep(cotaCellDep)
-	builder.AddCellDep(utils.JoyIDLockCellDep(network, nil))
-	txWithGroups, err := builder.Build()
+	// The handler adds CoTA cell dep and subkey unlock smt to WitnessArgs.OutputType
+	builder.Register(handler.NewJoyIDScriptHandler(network))
+	txWithGroups, err := builder.Build(&handler.JoyIDUnlockContext{
+		Mode:             signer.SubkeyUnlock,
+		Alg:              algKey.Alg,
+		SubkeyPubkeyHash: algKey.PubkeyHash(),
+		AggregatorUrl:    testnetAggregatorUrl,
+		IndexerUrl:       testnetCkbIndexerUrl,
+	})
 	if err != nil {
 		return err
 	}
 
-	// Init WitnessArgs
 	tx := txWithGroups.TxView
-	witnessArgs := types.WitnessArgs{
-		Lock: []byte{},
-	}
-	tx.Witnesses = [][]byte{witnessArgs.Serialize()}
-
-	// Add subkey unlock smt to WitnessArgs.Output
-	algKey := signer.AlgPrivKey{
-		PrivKey: senderSubkeyPrivKey,
-		Alg:     alg.Secp256k1,
-	}
-	signer.BuildOutputTypeWithSubkeySmt(tx, algKey, senderAddr, testnetAggregatorUrl)
+	group := signer.FindLockScriptGroup(txWithGroups.ScriptGroups, senderAddr.Script)
 
 	// Sign transaction
-	signer.SignSubkeyUnlockTx(tx, algKey, nil)
+	if err := signer.SignSubkeyUnlockTx(tx, group, algKey.Signer(nil)); err != nil {
+		return err
+	}
 
 	// send transaction
 	hash, err := client.SendTransaction(context.Background(), tx)
@@ -261,7 +271,7 @@ func AddSecp256r1SubkeyWithNativeUnlock() error {
 	senderPrivKey := "0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761"
 	sender := "ckt1qqr4jkln4qmtmdle82g6vm9jer967rvq069danwunkgs4tr0pfws7qgqq9sfrkfah2cj79nyp7e6p283ualq8779rsgww3jf"
 	senderSubkeyPrivKey := "0x86f850ed0e871df5abb188355cd6fe00809063c6bdfd822f420f2d0a8a7c985d"
-	// extData must be unique for each subkey
+	// extData must be unique for each subkey, and subkey.Manager.NextExtData suggests the next free one
 	var extData uint32 = 1
 	network := types.NetworkTest
 	client, err := rpc.Dial(testnetCkbNodeUrl)
@@ -292,7 +302,11 @@ func AddSecp256r1SubkeyWithNativeUnlock() error {
 	}
 
 	rpc := aggregator.NewRPCClient(testnetAggregatorUrl)
-	pubkeyHash := secp256r1.ImportKey(senderSubkeyPrivKey).PubkeyHash()
+	subkeyKey, err := secp256r1.ImportKeyHex(senderSubkeyPrivKey)
+	if err != nil {
+		return err
+	}
+	pubkeyHash := subkeyKey.PubkeyHash()
 	extensionSubkeySmt, err := rpc.GetExtensionSubkeySmt(senderAddr, pubkeyHash, alg.Secp256r1, extData)
 	if err != nil {
 		return err
@@ -301,7 +315,7 @@ func AddSecp256r1SubkeyWithNativeUnlock() error {
 	if err != nil {
 		return err
 	}
-	witnessInputType := []byte{0xF0}
+	witnessInputType := []byte{cota.ActionExtension}
 	witnessInputType = append(witnessInputType, extSubkeySmtEntry...)
 	cotaSmtRoot, err := utils.HexToBytes(extensionSubkeySmt.SmtRootHash)
 	if err != nil {
@@ -321,18 +335,24 @@ func AddSecp256r1SubkeyWithNativeUnlock() error {
 		CellDeps:    []*types.CellDep{cotaCellDep, utils.JoyIDLockCellDep(network, nil), utils.CotaTypeCellDep(network)},
 		Witnesses:   [][]byte{witnessArgs.Serialize()},
 	}
-
-	// Build webAuthn message
-	webAuthnMsg, err := generateWebAuthnMsg(tx)
-	if err != nil {
-		return err
+	group := &transaction.ScriptGroup{
+		Script:       cotaCell.Output.Lock,
+		GroupType:    types.ScriptTypeLock,
+		InputIndices: []uint32{0},
 	}
+
 	algKey := signer.AlgPrivKey{
 		PrivKey: senderPrivKey,
 		Alg:     alg.Secp256r1,
 	}
 	// Sign transaction
-	signer.SignNativeUnlockTx(tx, algKey, webAuthnMsg)
+	authenticatorSigner, err := newAuthenticatorSigner(algKey.PrivKey)
+	if err != nil {
+		return err
+	}
+	if err := signer.SignNativeUnlockTx(tx, group, authenticatorSigner); err != nil {
+		return err
+	}
 
 	// send transaction
 	hash, err := client.SendTransaction(context.Background(), tx)
@@ -347,7 +367,7 @@ func AddSecp256k1SubkeyWithNativeUnlock() error {
 	senderPrivKey := "0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761"
 	sender := "ckt1qqr4jkln4qmtmdle82g6vm9jer967rvq069danwunkgs4tr0pfws7qgqqfjsplqwsm75nmmal39jth7k2n4v4t2nlvmef595"
 	senderSubkeyPrivKey := "0x86f850ed0e871df5abb188355cd6fe00809063c6bdfd822f420f2d0a8a7c985d"
-	// extData must be unique for each subkey
+	// extData must be unique for each subkey, and subkey.Manager.NextExtData suggests the next free one
 	var extData uint32 = 1
 	network := types.NetworkTest
 	client, err := rpc.Dial(testnetCkbNodeUrl)
@@ -378,7 +398,11 @@ func AddSecp256k1SubkeyWithNativeUnlock() error {
 	}
 
 	rpc := aggregator.NewRPCClient(testnetAggregatorUrl)
-	pubkeyHash := secp256k1.ImportKey(senderSubkeyPrivKey).PubkeyHash()
+	subkeyKey, err := secp256k1.ImportKeyHex(senderSubkeyPrivKey)
+	if err != nil {
+		return err
+	}
+	pubkeyHash := subkeyKey.PubkeyHash()
 
 	extensionSubkeySmt, err := rpc.GetExtensionSubkeySmt(senderAddr, pubkeyHash, alg.Secp256k1, extData)
 	if err != nil {
@@ -388,7 +412,7 @@ func AddSecp256k1SubkeyWithNativeUnlock() error {
 	if err != nil {
 		return err
 	}
-	witnessInputType := []byte{0xF0}
+	witnessInputType := []byte{cota.ActionExtension}
 	witnessInputType = append(witnessInputType, extSubkeySmtEntry...)
 	cotaSmtRoot, err := utils.HexToBytes(extensionSubkeySmt.SmtRootHash)
 	if err != nil {
@@ -408,14 +432,20 @@ func AddSecp256k1SubkeyWithNativeUnlock() error {
 		CellDeps:    []*types.CellDep{cotaCellDep, utils.JoyIDLockCellDep(network, nil), utils.CotaTypeCellDep(network)},
 		Witnesses:   [][]byte{witnessArgs.Serialize()},
 	}
+	group := &transaction.ScriptGroup{
+		Script:       cotaCell.Output.Lock,
+		GroupType:    types.ScriptTypeLock,
+		InputIndices: []uint32{0},
+	}
 
-	// Build webAuthn message
 	algKey := signer.AlgPrivKey{
 		PrivKey: senderPrivKey,
 		Alg:     alg.Secp256k1,
 	}
 	// Sign transaction
-	signer.SignNativeUnlockTx(tx, algKey, nil)
+	if err := signer.SignNativeUnlockTx(tx, group, algKey.Signer(nil)); err != nil {
+		return err
+	}
 
 	// send transaction
 	hash, err := client.SendTransaction(context.Background(), tx)
@@ -426,18 +456,66 @@ func AddSecp256k1SubkeyWithNativeUnlock() error {
 	return nil
 }
 
-// AuthData: https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
-// ClientData: https://www.w3.org/TR/webauthn-2/#clientdatajson-serialization
-func generateWebAuthnMsg(tx *types.Transaction) (*signer.WebAuthnMsg, error) {
-	authData := "49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630162f9fb77"
-	challenge, err := signer.GenerateWebAuthnChallenge(tx)
+func UpdateSecp256r1SubkeyWithNativeUnlock() error {
+	senderPrivKey := "0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761"
+	sender := "ckt1qqr4jkln4qmtmdle82g6vm9jer967rvq069danwunkgs4tr0pfws7qgqq9sfrkfah2cj79nyp7e6p283ualq8779rsgww3jf"
+	// the subkey of the new device replaces the one of the lost device in the ext_data slot
+	var extData uint32 = 1
+	network := types.NetworkTest
+	client, err := rpc.Dial(testnetCkbNodeUrl)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	clientData := fmt.Sprintf("7b2274797065223a22776562617574686e2e676574222c226368616c6c656e6765223a22%s222c226f726967696e223a22687474703a2f2f6c6f63616c686f73743a38303030222c2263726f73734f726967696e223a66616c73657d", challenge)
-	webAuthn := &signer.WebAuthnMsg{
-		AuthData:   authData,
-		ClientData: clientData,
+	senderAddr, err := address.Decode(sender)
+	if err != nil {
+		return err
+	}
+
+	// the pubkey hash of the passkey of the new device, whose private key never leaves the device
+	newPubkeyHash, err := utils.HexToBytes("0x5f948afa67bfee05fa5ad9659cde4fa6a28ac5ce")
+	if err != nil {
+		return err
+	}
+	manager := subkey.NewManager(network, testnetAggregatorUrl, testnetCkbIndexerUrl)
+	txWithGroups, err := manager.BuildUpdateTx(context.Background(), senderAddr, []subkey.Subkey{{
+		ExtData:    extData,
+		Alg:        alg.Secp256r1,
+		PubkeyHash: newPubkeyHash,
+	}}, nil)
+	if err != nil {
+		return err
+	}
+	tx := txWithGroups.TxView
+	group := signer.FindLockScriptGroup(txWithGroups.ScriptGroups, senderAddr.Script)
+
+	algKey := signer.AlgPrivKey{
+		PrivKey: senderPrivKey,
+		Alg:     alg.Secp256r1,
+	}
+	// Sign transaction
+	authenticatorSigner, err := newAuthenticatorSigner(algKey.PrivKey)
+	if err != nil {
+		return err
+	}
+	if err := signer.SignNativeUnlockTx(tx, group, authenticatorSigner); err != nil {
+		return err
+	}
+
+	// send transaction
+	hash, err := client.SendTransaction(context.Background(), tx)
+	if err != nil {
+		return err
+	}
+	fmt.Println("the tx hash of updating extension secp256r1 subkey with secp256r1 native unlock: " + utils.BytesTo0xHex(hash.Bytes()))
+	return nil
+}
+
+// newAuthenticatorSigner signs with the software authenticator of the passkey for localhost, and its
+// authData and clientData are the same as the browser's
+func newAuthenticatorSigner(privKey string) (signer.Signer, error) {
+	key, err := secp256r1.ImportKeyHex(privKey)
+	if err != nil {
+		return nil, err
 	}
-	return webAuthn, nil
+	return signer.NewAuthenticatorSigner(webauthn.NewAuthenticator(key, "localhost", webAuthnOrigin)), nil
 }
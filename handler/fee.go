@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// WebAuthnFeeEstimator estimates the fee with the final size of the secp256r1 witness lock whose
+// length depends on authData and the origin and crossOrigin in clientData
+type WebAuthnFeeEstimator struct {
+	FeeRate     uint64
+	AuthDataLen int
+	Origin      string
+	CrossOrigin bool
+}
+
+// WebAuthnMsgLen returns the exact length of authData and clientData, and it can be set to
+// JoyIDUnlockContext.WebAuthnMsgLen to build the transaction with the exact fee
+func (e *WebAuthnFeeEstimator) WebAuthnMsgLen() int {
+	return signer.WebAuthnMsgLen(e.AuthDataLen, e.Origin, e.CrossOrigin)
+}
+
+// AdjustChangeOutput resizes the secp256r1 witness lock placeholder of the JoyID lock script group
+// to the final length and sets the change output to pay the fee of the final size. InputCapacity is
+// the total capacity of the inputs, and the fee paid by the builder is the inputs minus the outputs.
+func (e *WebAuthnFeeEstimator) AdjustChangeOutput(tx *types.Transaction, group *transaction.ScriptGroup, changeIndex int, inputCapacity uint64) error {
+	if changeIndex < 0 || changeIndex >= len(tx.Outputs) {
+		return fmt.Errorf("change output index %d is out of range", changeIndex)
+	}
+	if group == nil || len(group.InputIndices) == 0 || int(group.InputIndices[0]) >= len(tx.Witnesses) {
+		return errors.New("invalid JoyID lock script group")
+	}
+	outputCapacity := tx.OutputsCapacity()
+	if inputCapacity < outputCapacity {
+		return errors.New("input capacity is less than output capacity")
+	}
+	index := group.InputIndices[0]
+	witnessArgs := &types.WitnessArgs{}
+	if len(tx.Witnesses[index]) > 0 {
+		var err error
+		if witnessArgs, err = types.DeserializeWitnessArgs(tx.Witnesses[index]); err != nil {
+			return err
+		}
+	}
+
+	paidFee := inputCapacity - outputCapacity
+	witnessArgs.Lock = witness.Placeholder(alg.Secp256r1, e.WebAuthnMsgLen())
+	tx.Witnesses[index] = witnessArgs.Serialize()
+	newFee := tx.CalculateFee(e.FeeRate)
+
+	changeOutput := tx.Outputs[changeIndex]
+	if changeOutput.Capacity+paidFee < newFee {
+		return errors.New("no enough capacity for the fee")
+	}
+	capacity := changeOutput.Capacity + paidFee - newFee
+	if capacity < changeOutput.OccupiedCapacity(tx.OutputsData[changeIndex]) {
+		return errors.New("change output capacity is less than its occupied capacity")
+	}
+	changeOutput.Capacity = capacity
+	return nil
+}
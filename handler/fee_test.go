@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/collector/builder"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func TestAdjustChangeOutput(t *testing.T) {
+	b := builder.NewSimpleTransactionBuilder(types.NetworkTest)
+	group := testScriptGroup(alg.Secp256r1, b)
+	if _, err := NewJoyIDScriptHandler(types.NetworkTest).BuildTransaction(b, group, nil); err != nil {
+		t.Fatal(err)
+	}
+	changeCapacity := uint64(100000000000)
+	b.AddOutput(&types.CellOutput{Capacity: changeCapacity, Lock: group.Script}, []byte{})
+	tx := b.BuildTransaction().TxView
+
+	estimator := &WebAuthnFeeEstimator{FeeRate: 5000, AuthDataLen: 37, Origin: "https://app.joy.id/some/long/path/to/make/the/origin/longer/than/the/default/length"}
+	// the builder paid the fee of the default witness lock length with a different fee rate
+	paidFee := uint64(1234)
+	inputCapacity := changeCapacity + paidFee
+	if err := estimator.AdjustChangeOutput(tx, group, 0, inputCapacity); err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, _ := types.DeserializeWitnessArgs(tx.Witnesses[0])
+	if got, want := len(witnessArgs.Lock), 129+estimator.WebAuthnMsgLen(); got != want {
+		t.Errorf("witness lock length = %d, want %d", got, want)
+	}
+	newFee := tx.CalculateFee(estimator.FeeRate)
+	if got, want := tx.Outputs[0].Capacity, inputCapacity-newFee; got != want {
+		t.Errorf("change capacity = %d, want %d", got, want)
+	}
+
+	lockLen := len(witnessArgs.Lock)
+	estimator.CrossOrigin = true
+	if err := estimator.AdjustChangeOutput(tx, group, 0, inputCapacity); err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, _ = types.DeserializeWitnessArgs(tx.Witnesses[0])
+	// "crossOrigin":true is one byte shorter than false
+	if got, want := len(witnessArgs.Lock), lockLen-1; got != want {
+		t.Errorf("witness lock length = %d, want %d", got, want)
+	}
+	if got, want := tx.Outputs[0].Capacity, inputCapacity-tx.CalculateFee(estimator.FeeRate); got != want {
+		t.Errorf("change capacity = %d, want %d", got, want)
+	}
+
+	if err := estimator.AdjustChangeOutput(tx, group, 0, tx.OutputsCapacity()-1); err == nil {
+		t.Errorf("AdjustChangeOutput() should fail with input capacity less than output capacity")
+	}
+	if err := estimator.AdjustChangeOutput(tx, group, 1, inputCapacity); err == nil {
+		t.Errorf("AdjustChangeOutput() should fail with out of range change index")
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/deployment"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/collector"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const (
+	// authData(37 bytes) + clientData with an origin of up to 63 characters
+	defaultWebAuthnMsgLen = 256
+)
+
+// JoyIDUnlockContext is the context of builder.Build to describe how the JoyID lock will be unlocked,
+// and native unlock with the alg of the lock args is used if no context is given
+type JoyIDUnlockContext struct {
+	// Lock is the JoyID lock script which the context applies to, and the context applies to every
+	// JoyID lock script group if it is nil
+	Lock *types.Script
+	Mode signer.UnlockMode
+	// Alg is the alg of the signing key, the alg of the lock args is used for native unlock if it is zero
+	Alg alg.AlgIndex
+	// WebAuthnMsgLen is the total length of authData and clientData of secp256r1 and a default length
+	// is used if it is zero
+	WebAuthnMsgLen int
+
+	// SubkeyPubkeyHash, AggregatorUrl and IndexerUrl are required by subkey unlock
+	SubkeyPubkeyHash []byte
+	AggregatorUrl    string
+	IndexerUrl       string
+	// Aggregator replaces the client of AggregatorUrl, e.g. with custom headers or http.Client
+	Aggregator *aggregator.RPCClient
+
+	// subkeyUnlocks are the subkey unlocks of the current build by the lock script hash
+	subkeyUnlocks map[types.Hash]*subkeyUnlock
+}
+
+// subkeyUnlock is the subkey unlock smt entry and the CoTA cell dep of a JoyID lock
+type subkeyUnlock struct {
+	unlockEntry []byte
+	cotaCellDep *types.CellDep
+}
+
+type JoyIDScriptHandler struct {
+	CellDep  *types.CellDep
+	CodeHash types.Hash
+	network  types.Network
+}
+
+func NewJoyIDScriptHandler(network types.Network) *JoyIDScriptHandler {
+	if network != types.NetworkMain && network != types.NetworkTest {
+		return nil
+	}
+	return NewJoyIDScriptHandlerWithDeployment(deployment.Get(network))
+}
+
+// NewJoyIDScriptHandlerWithDeployment returns the handler of the JoyID lock of the deployment, e.g. the devnet
+func NewJoyIDScriptHandlerWithDeployment(d *deployment.Deployment) *JoyIDScriptHandler {
+	return &JoyIDScriptHandler{
+		CellDep:  d.JoyIDLock.CellDep(),
+		CodeHash: d.JoyIDLock.CodeHash,
+		network:  d.Network,
+	}
+}
+
+func (r *JoyIDScriptHandler) isMatched(script *types.Script) bool {
+	if script == nil {
+		return false
+	}
+	return script.CodeHash == r.CodeHash
+}
+
+func (r *JoyIDScriptHandler) BuildTransaction(builder collector.TransactionBuilder, group *transaction.ScriptGroup, context interface{}) (bool, error) {
+	if group == nil || group.GroupType != types.ScriptTypeLock || !r.isMatched(group.Script) {
+		return false, nil
+	}
+	var ctx *JoyIDUnlockContext
+	switch c := context.(type) {
+	case *JoyIDUnlockContext:
+		if c.Lock != nil && !c.Lock.Equals(group.Script) {
+			return false, nil
+		}
+		ctx = c
+	case nil:
+		ctx = &JoyIDUnlockContext{Mode: signer.NativeUnlock}
+	default:
+		return false, nil
+	}
+	if len(group.Script.Args) < 2 {
+		return false, errors.New("invalid JoyID lock args")
+	}
+	index := uint(group.InputIndices[0])
+	algIndex := ctx.Alg
+	if algIndex == 0 {
+		if ctx.Mode != signer.NativeUnlock {
+			return false, errors.New("alg of the subkey cannot be empty")
+		}
+		algIndex = alg.AlgIndex(group.Script.Args[1])
+	}
+	webAuthnMsgLen := ctx.WebAuthnMsgLen
+	if webAuthnMsgLen == 0 {
+		webAuthnMsgLen = defaultWebAuthnMsgLen
+	}
+
+	lock := witness.Placeholder(algIndex, webAuthnMsgLen)
+	if err := builder.SetWitness(index, types.WitnessTypeLock, lock); err != nil {
+		return false, err
+	}
+	builder.AddCellDep(r.CellDep)
+
+	switch ctx.Mode {
+	case signer.NativeUnlock:
+	case signer.SubkeyUnlock:
+		unlock, err := ctx.loadSubkeyUnlock(group, algIndex, r.network)
+		if err != nil {
+			return false, err
+		}
+		if err := builder.SetWitness(index, types.WitnessTypeOutputType, unlock.unlockEntry); err != nil {
+			return false, err
+		}
+		builder.AddCellDep(unlock.cotaCellDep)
+	default:
+		return false, fmt.Errorf("unknown JoyID unlock mode %d", ctx.Mode)
+	}
+	return true, nil
+}
+
+// loadSubkeyUnlock fetches the subkey unlock smt entry and the CoTA cell dep of the lock of the group when
+// its first input is added, and they are reused for the later inputs of the group in the same build because
+// the builder calls the handler every time an input is added into the script group
+func (ctx *JoyIDUnlockContext) loadSubkeyUnlock(group *transaction.ScriptGroup, algIndex alg.AlgIndex, network types.Network) (*subkeyUnlock, error) {
+	lockHash := group.Script.Hash()
+	if unlock, ok := ctx.subkeyUnlocks[lockHash]; ok && len(group.InputIndices) > 1 {
+		return unlock, nil
+	}
+	if len(ctx.SubkeyPubkeyHash) == 0 {
+		return nil, errors.New("subkey pubkey hash cannot be empty")
+	}
+	addr := &address.Address{
+		Script:  group.Script,
+		Network: network,
+	}
+	rpc := ctx.Aggregator
+	if rpc == nil {
+		rpc = aggregator.NewRPCClient(ctx.AggregatorUrl)
+	}
+	unlockSmt, err := rpc.GetSubkeyUnlockSmt(addr, ctx.SubkeyPubkeyHash, algIndex)
+	if err != nil {
+		return nil, err
+	}
+	unlockEntry, err := utils.HexToBytes(unlockSmt)
+	if err != nil {
+		return nil, errors.New("hex convert error")
+	}
+	cotaCellDep, err := utils.CotaCellDep(ctx.IndexerUrl, addr)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.subkeyUnlocks == nil {
+		ctx.subkeyUnlocks = make(map[types.Hash]*subkeyUnlock)
+	}
+	unlock := &subkeyUnlock{unlockEntry: unlockEntry, cotaCellDep: cotaCellDep}
+	ctx.subkeyUnlocks[lockHash] = unlock
+	return unlock, nil
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/collector/builder"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func testScriptGroup(algIndex alg.AlgIndex, b *builder.SimpleTransactionBuilder) *transaction.ScriptGroup {
+	pubkeyHash, _ := utils.HexToBytes("0x6091d93dbab12f16640fb3a0a8f1e77e03fbc51c")
+	index := b.AddInput(&types.CellInput{PreviousOutput: &types.OutPoint{}})
+	return &transaction.ScriptGroup{
+		Script:       joyidaddress.DefaultJoyIDLock().FromPubkeyHash(pubkeyHash, algIndex).Script,
+		GroupType:    types.ScriptTypeLock,
+		InputIndices: []uint32{uint32(index)},
+	}
+}
+
+func TestBuildNativeUnlockPlaceholder(t *testing.T) {
+	testcases := []struct {
+		alg     alg.AlgIndex
+		context interface{}
+		wantLen int
+	}{
+		{alg.Secp256k1, nil, 86},
+		{alg.Secp256r1, nil, 129 + defaultWebAuthnMsgLen},
+		{alg.Secp256r1, &JoyIDUnlockContext{Mode: signer.NativeUnlock, WebAuthnMsgLen: 200}, 329},
+	}
+	for _, tc := range testcases {
+		b := builder.NewSimpleTransactionBuilder(types.NetworkTest)
+		group := testScriptGroup(tc.alg, b)
+		handler := NewJoyIDScriptHandler(types.NetworkTest)
+		handled, err := handler.BuildTransaction(b, group, tc.context)
+		if err != nil || !handled {
+			t.Fatalf("BuildTransaction() = %t, %v, want true, nil", handled, err)
+		}
+		witnessArgs, err := types.DeserializeWitnessArgs(b.Witnesses[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(witnessArgs.Lock); got != tc.wantLen {
+			t.Errorf("witness lock length = %d, want %d", got, tc.wantLen)
+		}
+		if len(b.CellDeps) != 1 || !bytes.Equal(b.CellDeps[0].OutPoint.TxHash.Bytes(), handler.CellDep.OutPoint.TxHash.Bytes()) {
+			t.Errorf("JoyID lock cell dep should be added")
+		}
+	}
+}
+
+func TestBuildSkipsOtherContexts(t *testing.T) {
+	b := builder.NewSimpleTransactionBuilder(types.NetworkTest)
+	group := testScriptGroup(alg.Secp256k1, b)
+	handler := NewJoyIDScriptHandler(types.NetworkTest)
+	otherLock := &types.Script{CodeHash: group.Script.CodeHash, HashType: types.HashTypeType, Args: []byte{0x00, 0x02}}
+	for _, context := range []interface{}{"other context", &JoyIDUnlockContext{Lock: otherLock, Mode: signer.NativeUnlock}} {
+		handled, err := handler.BuildTransaction(b, group, context)
+		if err != nil || handled {
+			t.Errorf("BuildTransaction() = %t, %v, want false, nil", handled, err)
+		}
+	}
+	if len(b.Witnesses[0]) != 0 || len(b.CellDeps) != 0 {
+		t.Errorf("transaction should not be changed")
+	}
+}
+
+// testSubkeyServer serves the subkey unlock entry of lock_script | the count of requests and the CoTA cell
+// whose out point tx hash is the CoTA type args
+func testSubkeyServer(t *testing.T, calls *int) *httptest.Server {
+	node, server := testutil.NewNode(t)
+	node.Handlers["generate_subkey_unlock_smt"] = func(params json.RawMessage) string {
+		*calls++
+		var req struct {
+			LockScript string `json:"lock_script"`
+		}
+		if err := json.Unmarshal(params, &req); err != nil {
+			t.Error(err)
+		}
+		return fmt.Sprintf(`{"unlock_entry":"%s%02x","block_number":1}`, req.LockScript, *calls)
+	}
+	node.Handlers["get_cells"] = func(params json.RawMessage) string {
+		var args []json.RawMessage
+		var searchKey struct {
+			Script *types.Script `json:"script"`
+		}
+		if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 || json.Unmarshal(args[0], &searchKey) != nil {
+			t.Errorf("invalid get_cells params %s", params)
+			return "null"
+		}
+		cell := map[string]interface{}{
+			"block_number": "0x1",
+			"out_point":    types.OutPoint{TxHash: types.BytesToHash(searchKey.Script.Args), Index: 0},
+			"output":       types.CellOutput{Capacity: 500_00000000, Lock: searchKey.Script, Type: searchKey.Script},
+			"output_data":  "0x",
+			"tx_index":     "0x0",
+		}
+		result, _ := json.Marshal(map[string]interface{}{"last_cursor": "0x01", "objects": []interface{}{cell}})
+		return string(result)
+	}
+	return server
+}
+
+func TestBuildSubkeyUnlockWithTwoGroups(t *testing.T) {
+	calls := 0
+	server := testSubkeyServer(t, &calls)
+	defer server.Close()
+	handler := NewJoyIDScriptHandler(types.NetworkTest)
+	subkeyHash, _ := utils.HexToBytes("0x724acb22b1dead86b78f1375c3d176a4a2943653")
+	ctx := &JoyIDUnlockContext{
+		Mode:             signer.SubkeyUnlock,
+		Alg:              alg.Secp256k1,
+		SubkeyPubkeyHash: subkeyHash,
+		AggregatorUrl:    server.URL,
+		IndexerUrl:       server.URL,
+	}
+	newGroup := func(b *builder.SimpleTransactionBuilder, pubkeyHash string) *transaction.ScriptGroup {
+		hash, _ := utils.HexToBytes(pubkeyHash)
+		index := b.AddInput(&types.CellInput{PreviousOutput: &types.OutPoint{}})
+		return &transaction.ScriptGroup{
+			Script:       joyidaddress.DefaultJoyIDLock().FromPubkeyHash(hash, alg.Secp256r1).Script,
+			GroupType:    types.ScriptTypeLock,
+			InputIndices: []uint32{uint32(index)},
+		}
+	}
+	checkGroup := func(b *builder.SimpleTransactionBuilder, group *transaction.ScriptGroup, wantCall int) {
+		witnessArgs, err := types.DeserializeWitnessArgs(b.Witnesses[group.InputIndices[0]])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := append(group.Script.Serialize(), byte(wantCall))
+		if !bytes.Equal(witnessArgs.OutputType, want) {
+			t.Errorf("unlock entry = %x, want %x", witnessArgs.OutputType, want)
+		}
+		cotaDep := types.BytesToHash(group.Script.Hash().Bytes()[:20])
+		found := false
+		for _, cellDep := range b.CellDeps {
+			found = found || cellDep.OutPoint.TxHash == cotaDep
+		}
+		if !found {
+			t.Errorf("CoTA cell dep of lock %x should be added", group.Script.Args)
+		}
+	}
+
+	b := builder.NewSimpleTransactionBuilder(types.NetworkTest)
+	group1 := newGroup(b, "0x6091d93dbab12f16640fb3a0a8f1e77e03fbc51c")
+	group2 := newGroup(b, "0x724acb22b1dead86b78f1375c3d176a4a2943653")
+	for _, group := range []*transaction.ScriptGroup{group1, group2} {
+		if handled, err := handler.BuildTransaction(b, group, ctx); err != nil || !handled {
+			t.Fatalf("BuildTransaction() = %t, %v, want true, nil", handled, err)
+		}
+	}
+	checkGroup(b, group1, 1)
+	checkGroup(b, group2, 2)
+
+	// the later inputs of the group reuse the entry of the current build
+	group1.InputIndices = append(group1.InputIndices, uint32(b.AddInput(&types.CellInput{PreviousOutput: &types.OutPoint{}})))
+	if _, err := handler.BuildTransaction(b, group1, ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkGroup(b, group1, 1)
+	if calls != 2 {
+		t.Errorf("aggregator calls = %d, want 2", calls)
+	}
+
+	// the next build fetches the entry again
+	b = builder.NewSimpleTransactionBuilder(types.NetworkTest)
+	group1 = newGroup(b, "0x6091d93dbab12f16640fb3a0a8f1e77e03fbc51c")
+	if _, err := handler.BuildTransaction(b, group1, ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkGroup(b, group1, 3)
+}
@@ -0,0 +1,129 @@
+// Package testutil is the fixtures of the tests which build transactions with the mock indexer and aggregator
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// Address returns the testnet JoyID address of the secp256r1 key which the tests build transactions for
+func Address() *address.Address {
+	pubkeyHash, _ := utils.HexToBytes("0x6091d93dbab12f16640fb3a0a8f1e77e03fbc51c")
+	return &address.Address{
+		Script:  joyidaddress.DefaultJoyIDLock().FromPubkeyHash(pubkeyHash, alg.Secp256r1).Script,
+		Network: types.NetworkTest,
+	}
+}
+
+// BytesOf returns the bytes of the length filled with b
+func BytesOf(length int, b byte) []byte {
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = b
+	}
+	return data
+}
+
+// Node mocks the indexer and the aggregator. The result of a method is generated by its handler or taken
+// from Results, get_cells returns the CoTA cell of Address without a handler, and the other methods are
+// not found. The batch requests are served request by request.
+type Node struct {
+	Results  map[string]string
+	Handlers map[string]func(params json.RawMessage) string
+	// Params are the params of the last request of each method
+	Params map[string]json.RawMessage
+	// Batches is the count of the batch requests
+	Batches int
+
+	t *testing.T
+}
+
+// NewNode returns the node and its server which should be closed by the test
+func NewNode(t *testing.T) (*Node, *httptest.Server) {
+	node := &Node{
+		Results:  map[string]string{},
+		Handlers: map[string]func(params json.RawMessage) string{},
+		Params:   map[string]json.RawMessage{},
+		t:        t,
+	}
+	return node, httptest.NewServer(node)
+}
+
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		n.t.Error(err)
+		return
+	}
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		var reqs []json.RawMessage
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			n.t.Error(err)
+			return
+		}
+		n.Batches++
+		resps := make([]string, 0, len(reqs))
+		for _, req := range reqs {
+			resps = append(resps, n.serve(req))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(resps, ","))
+		return
+	}
+	fmt.Fprint(w, n.serve(body))
+}
+
+// serve returns the response of the JSON-RPC request
+func (n *Node) serve(body []byte) string {
+	var req struct {
+		Id     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		n.t.Error(err)
+		return "null"
+	}
+	n.Params[req.Method] = req.Params
+	result, ok := n.Results[req.Method]
+	if handler, exist := n.Handlers[req.Method]; exist {
+		result, ok = handler(req.Params), true
+	} else if req.Method == "get_cells" {
+		result, ok = n.cotaCell(req.Params), true
+	}
+	if !ok {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"Method not found"}}`, req.Id)
+	}
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%s}`, req.Id, result)
+}
+
+// cotaCell returns the CoTA cell of Address with the type script of the search key
+func (n *Node) cotaCell(params json.RawMessage) string {
+	var args []json.RawMessage
+	var searchKey struct {
+		Script *types.Script `json:"script"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 || json.Unmarshal(args[0], &searchKey) != nil {
+		n.t.Errorf("invalid get_cells params %s", params)
+		return "null"
+	}
+	cell, _ := json.Marshal(map[string]interface{}{
+		"block_number": "0x1",
+		"out_point":    types.OutPoint{TxHash: types.Hash{0x01}, Index: 0},
+		"output":       types.CellOutput{Capacity: 500_00000000, Lock: Address().Script, Type: searchKey.Script},
+		"output_data":  utils.BytesTo0xHex(append([]byte{0x02}, make([]byte, 32)...)),
+		"tx_index":     "0x0",
+	})
+	return fmt.Sprintf(`{"last_cursor":"0x","objects":[%s]}`, cell)
+}
@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/webauthn"
+)
+
+// AuthenticatorSigner is the Secp256r1Signer of the software authenticator, and its WebAuthn message is
+// produced by the authenticator with the incrementing signCount instead of WebAuthnGenerator
+type AuthenticatorSigner struct {
+	Authenticator *webauthn.Authenticator
+	// Options checks the assertion of the authenticator if it isn't nil
+	Options *webauthn.VerifyOptions
+}
+
+// NewAuthenticatorSigner returns the signer of the authenticator whose assertions are checked against its RP ID and origin
+func NewAuthenticatorSigner(authenticator *webauthn.Authenticator) *AuthenticatorSigner {
+	return &AuthenticatorSigner{
+		Authenticator: authenticator,
+		Options: &webauthn.VerifyOptions{
+			RPID:    authenticator.RPID,
+			Origins: []string{authenticator.Origin},
+		},
+	}
+}
+
+func (s *AuthenticatorSigner) Alg() alg.AlgIndex {
+	return alg.Secp256r1
+}
+
+// Pubkey returns nil if the key of the authenticator is invalid
+func (s *AuthenticatorSigner) Pubkey() []byte {
+	pubkey := s.Authenticator.Key.PublicKey()
+	if pubkey == nil {
+		return nil
+	}
+	return pubkey.Bytes()
+}
+
+func (s *AuthenticatorSigner) PubkeyHash() []byte {
+	return s.Authenticator.Key.PubkeyHash()
+}
+
+// SignWebAuthn gets the assertion of the challenge from the authenticator, and returns the WebAuthn message
+// with the 64-byte r|s signature with low S
+func (s *AuthenticatorSigner) SignWebAuthn(challenge string) (*WebAuthnMsg, []byte, error) {
+	challengeBytes, err := utils.HexToBytes(challenge)
+	if err != nil {
+		return nil, nil, errors.New("hex convert error")
+	}
+	// the authenticator encodes the raw challenge with base64url as the browser does
+	rawChallenge, err := base64.RawURLEncoding.DecodeString(string(challengeBytes))
+	if err != nil {
+		return nil, nil, errors.New("challenge must be base64url encoded")
+	}
+	assertion, err := s.Authenticator.GetAssertion(rawChallenge)
+	if err != nil {
+		return nil, nil, err
+	}
+	webAuthnAssertion := &WebAuthnAssertion{
+		AuthenticatorData: assertion.AuthenticatorData,
+		ClientDataJSON:    assertion.ClientDataJSON,
+		Signature:         assertion.Signature,
+		CredentialPubkey:  s.Pubkey(),
+		Options:           s.Options,
+	}
+	return webAuthnAssertion.SignWebAuthn(challenge)
+}
@@ -0,0 +1,89 @@
+package signer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// FindLockScriptGroup returns the lock script group of the lock script, or nil if the
+// transaction has no input locked by it.
+func FindLockScriptGroup(groups []*transaction.ScriptGroup, lock *types.Script) *transaction.ScriptGroup {
+	for _, group := range groups {
+		if group.GroupType == types.ScriptTypeLock && group.Script.Equals(lock) {
+			return group
+		}
+	}
+	return nil
+}
+
+func checkLockScriptGroup(tx *types.Transaction, group *transaction.ScriptGroup) error {
+	if group == nil || len(group.InputIndices) == 0 {
+		return errors.New("script group input indices cannot be empty")
+	}
+	for i, index := range group.InputIndices {
+		if i > 0 && index <= group.InputIndices[i-1] {
+			return errors.New("script group input indices must be in ascending order")
+		}
+		if int(index) >= len(tx.Inputs) || int(index) >= len(tx.Witnesses) {
+			return fmt.Errorf("script group input index %d is out of range", index)
+		}
+	}
+	return nil
+}
+
+// groupWitnessArgs returns the WitnessArgs of the first witness of the group and an empty
+// witness is treated as an empty WitnessArgs.
+func groupWitnessArgs(tx *types.Transaction, group *transaction.ScriptGroup) (*types.WitnessArgs, error) {
+	if err := checkLockScriptGroup(tx, group); err != nil {
+		return nil, err
+	}
+	witness := tx.Witnesses[group.InputIndices[0]]
+	if len(witness) == 0 {
+		return &types.WitnessArgs{}, nil
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(witness)
+	if err != nil {
+		return nil, errors.New("first witness of script group must be WitnessArgs")
+	}
+	return witnessArgs, nil
+}
+
+func setGroupWitnessArgs(tx *types.Transaction, group *transaction.ScriptGroup, witnessArgs *types.WitnessArgs) {
+	tx.Witnesses[group.InputIndices[0]] = witnessArgs.Serialize()
+}
+
+// groupSigningMessage returns the message to be hashed as the sighash of the script group:
+// tx_hash | first witness of the group with an empty lock | other witnesses of the group |
+// witnesses beyond the inputs count, and each witness is prefixed with its length in u64 LE.
+func groupSigningMessage(tx *types.Transaction, group *transaction.ScriptGroup, emptyLockLen int) ([]byte, *types.WitnessArgs, error) {
+	firstWitnessArgs, err := groupWitnessArgs(tx, group)
+	if err != nil {
+		return nil, nil, err
+	}
+	emptyWitness := types.WitnessArgs{
+		Lock:       make([]byte, emptyLockLen),
+		InputType:  firstWitnessArgs.InputType,
+		OutputType: firstWitnessArgs.OutputType,
+	}
+
+	msg := tx.ComputeHash().Bytes()
+	msg = appendWitness(msg, emptyWitness.Serialize())
+	for _, index := range group.InputIndices[1:] {
+		msg = appendWitness(msg, tx.Witnesses[index])
+	}
+	for i := len(tx.Inputs); i < len(tx.Witnesses); i++ {
+		msg = appendWitness(msg, tx.Witnesses[i])
+	}
+	return msg, firstWitnessArgs, nil
+}
+
+func appendWitness(msg []byte, witness []byte) []byte {
+	bytesLen := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bytesLen, uint64(len(witness)))
+	msg = append(msg, bytesLen...)
+	return append(msg, witness...)
+}
@@ -0,0 +1,193 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+)
+
+// WebAuthnGenerator generates the authData and clientData of WebAuthn for the challenge
+type WebAuthnGenerator func(challenge string) (*WebAuthnMsg, error)
+
+// AlgPrivKey is the hex private key of the alg held in memory
+type AlgPrivKey struct {
+	PrivKey string
+	Alg     alg.AlgIndex
+}
+
+// PubkeyHash returns blake160 of secp256r1 pubkey or keccak160 of secp256k1 pubkey, and it is nil if
+// the key is invalid
+func (algKey AlgPrivKey) PubkeyHash() []byte {
+	return algKey.Signer(nil).PubkeyHash()
+}
+
+// Signer returns the in-memory signer of the private key, and webAuthn is only used by secp256r1. The
+// signer of the invalid key returns the error of the key when it signs, use Import to check the key.
+func (algKey AlgPrivKey) Signer(webAuthn WebAuthnGenerator) Signer {
+	if algKey.Alg == alg.Secp256r1 {
+		return NewSecp256r1KeySigner(algKey.PrivKey, webAuthn)
+	}
+	return NewSecp256k1KeySigner(algKey.PrivKey)
+}
+
+// Import returns the signer of the private key like Signer, or the error if the key is invalid
+func (algKey AlgPrivKey) Import(webAuthn WebAuthnGenerator) (Signer, error) {
+	if algKey.Alg == alg.Secp256r1 {
+		return ImportSecp256r1KeySigner(algKey.PrivKey, webAuthn)
+	}
+	if algKey.Alg != alg.Secp256k1 {
+		return nil, fmt.Errorf("unknown alg index %d", algKey.Alg)
+	}
+	return ImportSecp256k1KeySigner(algKey.PrivKey)
+}
+
+// Secp256k1KeySigner is the Secp256k1Signer with the private key held in memory
+type Secp256k1KeySigner struct {
+	key *secp256k1.Key
+	err error
+}
+
+// NewSecp256k1KeySigner returns the signer of the hex private key. The pubkey and the pubkey hash of the
+// invalid key are nil and Sign returns the error of the key, use ImportSecp256k1KeySigner to check the key.
+func NewSecp256k1KeySigner(privKey string) *Secp256k1KeySigner {
+	key, err := secp256k1.ImportKeyHex(privKey)
+	return &Secp256k1KeySigner{key: key, err: err}
+}
+
+// ImportSecp256k1KeySigner returns the signer of the hex private key, or the error if the key is invalid
+func ImportSecp256k1KeySigner(privKey string) (*Secp256k1KeySigner, error) {
+	key, err := secp256k1.ImportKeyHex(privKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecp256k1KeySignerWithKey(key), nil
+}
+
+// NewSecp256k1KeySignerWithKey returns the signer of the imported key, e.g. from ImportKeyPEM
+func NewSecp256k1KeySignerWithKey(key *secp256k1.Key) *Secp256k1KeySigner {
+	return &Secp256k1KeySigner{key: key}
+}
+
+func (s *Secp256k1KeySigner) Alg() alg.AlgIndex {
+	return alg.Secp256k1
+}
+
+func (s *Secp256k1KeySigner) Pubkey() []byte {
+	if s.key == nil {
+		return nil
+	}
+	_, pubkey := s.key.Pubkey()
+	return pubkey
+}
+
+func (s *Secp256k1KeySigner) PubkeyHash() []byte {
+	if s.key == nil {
+		return nil
+	}
+	return s.key.PubkeyHash()
+}
+
+func (s *Secp256k1KeySigner) Sign(digest []byte) ([]byte, error) {
+	if err := keyError(s.key == nil, s.err); err != nil {
+		return nil, err
+	}
+	signature := s.key.Sign(digest)
+	if len(signature) == 0 {
+		return nil, errors.New("secp256k1 sign error")
+	}
+	return signature, nil
+}
+
+// Secp256r1KeySigner is the Secp256r1Signer with the private key held in memory, and the WebAuthn
+// message is generated by WebAuthn instead of an authenticator
+type Secp256r1KeySigner struct {
+	key      *secp256r1.Key
+	err      error
+	WebAuthn WebAuthnGenerator
+}
+
+// NewSecp256r1KeySigner returns the signer of the hex private key. The pubkey and the pubkey hash of the
+// invalid key are nil and SignWebAuthn returns the error of the key, use ImportSecp256r1KeySigner to check
+// the key.
+func NewSecp256r1KeySigner(privKey string, webAuthn WebAuthnGenerator) *Secp256r1KeySigner {
+	key, err := secp256r1.ImportKeyHex(privKey)
+	return &Secp256r1KeySigner{key: key, err: err, WebAuthn: webAuthn}
+}
+
+// ImportSecp256r1KeySigner returns the signer of the hex private key, or the error if the key is invalid
+func ImportSecp256r1KeySigner(privKey string, webAuthn WebAuthnGenerator) (*Secp256r1KeySigner, error) {
+	key, err := secp256r1.ImportKeyHex(privKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecp256r1KeySignerWithKey(key, webAuthn), nil
+}
+
+// NewSecp256r1KeySignerWithKey returns the signer of the imported key, e.g. from ImportKeyPEM
+func NewSecp256r1KeySignerWithKey(key *secp256r1.Key, webAuthn WebAuthnGenerator) *Secp256r1KeySigner {
+	return &Secp256r1KeySigner{key: key, WebAuthn: webAuthn}
+}
+
+func (s *Secp256r1KeySigner) Alg() alg.AlgIndex {
+	return alg.Secp256r1
+}
+
+func (s *Secp256r1KeySigner) Pubkey() []byte {
+	if s.key == nil {
+		return nil
+	}
+	_, pubkey := s.key.Pubkey()
+	return pubkey
+}
+
+func (s *Secp256r1KeySigner) PubkeyHash() []byte {
+	if s.key == nil {
+		return nil
+	}
+	return s.key.PubkeyHash()
+}
+
+func (s *Secp256r1KeySigner) SignWebAuthn(challenge string) (*WebAuthnMsg, []byte, error) {
+	if err := keyError(s.key == nil, s.err); err != nil {
+		return nil, nil, err
+	}
+	if s.WebAuthn == nil {
+		return nil, nil, errors.New("webAuthn generator cannot be empty")
+	}
+	webAuthn, err := s.WebAuthn(challenge)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientData, err := utils.HexToBytes(webAuthn.ClientData)
+	if err != nil {
+		return nil, nil, errors.New("hex convert error")
+	}
+	authData, err := utils.HexToBytes(webAuthn.AuthData)
+	if err != nil {
+		return nil, nil, errors.New("hex convert error")
+	}
+	signData := append([]byte{}, authData...)
+	signData = append(signData, sha256.Sha256(clientData)...)
+	signature := s.key.Sign(sha256.Sha256(signData))
+	if len(signature) == 0 {
+		return nil, nil, errors.New("secp256r1 sign error")
+	}
+	return webAuthn, signature, nil
+}
+
+// keyError returns the import error of the key, or the error of the empty key, e.g. the nil key of
+// NewSecp256k1KeySignerWithKey
+func keyError(empty bool, err error) error {
+	if err != nil {
+		return err
+	}
+	if empty {
+		return errors.New("private key cannot be empty")
+	}
+	return nil
+}
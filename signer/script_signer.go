@@ -0,0 +1,125 @@
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/deployment"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/secp256k1"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	ckbsigner "github.com/nervosnetwork/ckb-sdk-go/v2/transaction/signer"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// JoyIDSignerConfig is the payload of transaction.Context to sign JoyID lock script groups
+// with the JoyIDScriptSigner
+type JoyIDSignerConfig struct {
+	Signer Signer
+	Mode   UnlockMode
+	// Lock is the JoyID lock script of the account, it is required by subkey unlock whose lock args
+	// cannot be derived from the subkey and it is optional for native unlock
+	Lock *types.Script
+}
+
+// JoyIDScriptSigner implements the ScriptSigner of ckb-sdk-go for JoyID lock script
+type JoyIDScriptSigner struct {
+}
+
+func init() {
+	RegisterDeployment(deployment.Mainnet)
+	RegisterDeployment(deployment.Testnet)
+}
+
+// RegisterDeployment registers JoyIDScriptSigner to the transaction signer of ckb-sdk-go for the JoyID
+// lock of the deployment, which is required by the custom deployments, e.g. the devnet
+func RegisterDeployment(d *deployment.Deployment) {
+	ckbsigner.GetTransactionSignerInstance(d.Network).RegisterLockSigner(d.JoyIDLock.CodeHash, &JoyIDScriptSigner{})
+}
+
+// placeholderKey is the secp256k1 key of the JoyID contexts, whose private key is the sha256 of
+// "joyid-sdk-go placeholder key". The built-in signers of ckb-sdk-go fail on the context without a key,
+// and they skip the context with this key because no lock is expected to be guarded by it.
+var placeholderKey, _ = secp256k1.HexToKey("0xca5d4172b043883b10eaa1afcc68cdf01969a15f88f51a1d92f393bf6bddcc49")
+
+// NewJoyIDContext returns the context for JoyIDScriptSigner, and JoyID keys are carried by the config
+// while the secp256k1 key of the context is a placeholder, so the context can be passed to the
+// transaction signer of ckb-sdk-go together with the contexts of the other locks
+func NewJoyIDContext(config *JoyIDSignerConfig) *transaction.Context {
+	return &transaction.Context{
+		Key:     placeholderKey,
+		Payload: config,
+	}
+}
+
+// SignTransaction signs the script groups of the transaction with the transaction signer of ckb-sdk-go
+// and JoyIDScriptSigner, and the contexts can be in any order. It is the same as signing with
+// GetTransactionSignerInstance(network) directly, except that the indices of the signed script groups
+// are returned in ascending order.
+func SignTransaction(network types.Network, tx *transaction.TransactionWithScriptGroups, contexts ...*transaction.Context) ([]int, error) {
+	var joyidContexts, otherContexts []*transaction.Context
+	for _, ctx := range contexts {
+		if _, ok := ctx.Payload.(*JoyIDSignerConfig); ok {
+			joyidContexts = append(joyidContexts, ctx)
+		} else {
+			otherContexts = append(otherContexts, ctx)
+		}
+	}
+	signed, err := ckbsigner.GetTransactionSignerInstance(network).SignTransaction(tx, otherContexts...)
+	if err != nil {
+		return signed, err
+	}
+	joyidSigner := &JoyIDScriptSigner{}
+	for i, group := range tx.ScriptGroups {
+		if group.GroupType != types.ScriptTypeLock || !deployment.IsJoyIDLock(group.Script) {
+			continue
+		}
+		for _, ctx := range joyidContexts {
+			ok, err := joyidSigner.SignTransaction(tx.TxView, group, ctx)
+			if err != nil {
+				return signed, err
+			}
+			if ok {
+				signed = append(signed, i)
+				break
+			}
+		}
+	}
+	sort.Ints(signed)
+	return signed, nil
+}
+
+func (s *JoyIDScriptSigner) SignTransaction(tx *types.Transaction, group *transaction.ScriptGroup, ctx *transaction.Context) (bool, error) {
+	config, ok := ctx.Payload.(*JoyIDSignerConfig)
+	if !ok {
+		return false, nil
+	}
+	if !config.isMatched(group.Script) {
+		return false, nil
+	}
+	switch config.Mode {
+	case NativeUnlock, SubkeyUnlock:
+	default:
+		return false, fmt.Errorf("unknown JoyID unlock mode %d", config.Mode)
+	}
+
+	if err := signTx(tx, group, config.Signer, config.Mode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (config *JoyIDSignerConfig) isMatched(script *types.Script) bool {
+	if script == nil || config.Signer == nil {
+		return false
+	}
+	if config.Lock != nil {
+		return config.Lock.Equals(script)
+	}
+	if config.Mode != NativeUnlock {
+		return false
+	}
+	args := joyidaddress.LockArgs(config.Signer.PubkeyHash(), config.Signer.Alg())
+	return bytes.Equal(script.Args, args)
+}
@@ -0,0 +1,127 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/systemscript"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	ckbsigner "github.com/nervosnetwork/ckb-sdk-go/v2/transaction/signer"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func testWebAuthnMsg(challenge string) (*WebAuthnMsg, error) {
+	authData := "49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630162f9fb77"
+	clientData := fmt.Sprintf("7b2274797065223a22776562617574686e2e676574222c226368616c6c656e6765223a22%s222c226f726967696e223a22687474703a2f2f6c6f63616c686f73743a38303030222c2263726f73734f726967696e223a66616c73657d", challenge)
+	return &WebAuthnMsg{AuthData: authData, ClientData: clientData}, nil
+}
+
+func TestJoyIDScriptSignerWithMixedLocks(t *testing.T) {
+	secpKey := "0x6c9ed03816e3111e49384b86ab3b6d7d1e6bcbe1d2d6c5f1e63a0d1a3c3b5a41"
+	k1Key := AlgPrivKey{"0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1", alg.Secp256k1}
+	r1Key := AlgPrivKey{"0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761", alg.Secp256r1}
+	joyidLock := func(key AlgPrivKey) *types.Script {
+		return joyidaddress.DefaultJoyIDLock().FromPubkeyHash(key.PubkeyHash(), key.Alg).Script
+	}
+
+	secpContext, err := transaction.NewContext(secpKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1Context := NewJoyIDContext(&JoyIDSignerConfig{Signer: k1Key.Signer(nil), Mode: NativeUnlock})
+	r1Context := NewJoyIDContext(&JoyIDSignerConfig{Signer: r1Key.Signer(testWebAuthnMsg), Mode: NativeUnlock})
+	orders := map[string][]*transaction.Context{
+		"secp256k1 first": {secpContext, k1Context, r1Context},
+		"JoyID first":     {r1Context, k1Context, secpContext},
+	}
+	signTransactions := map[string]func(*transaction.TransactionWithScriptGroups, ...*transaction.Context) ([]int, error){
+		"SignTransaction": func(tx *transaction.TransactionWithScriptGroups, contexts ...*transaction.Context) ([]int, error) {
+			return SignTransaction(types.NetworkTest, tx, contexts...)
+		},
+		"ckb-sdk-go signer": ckbsigner.GetTransactionSignerInstance(types.NetworkTest).SignTransaction,
+	}
+	for order, contexts := range orders {
+		for signName, signTransaction := range signTransactions {
+			name := order + " with " + signName
+			tx := &transaction.TransactionWithScriptGroups{
+				TxView: testTransaction(3),
+				ScriptGroups: []*transaction.ScriptGroup{
+					{
+						Script: &types.Script{
+							CodeHash: systemscript.GetCodeHash(types.NetworkTest, systemscript.Secp256k1Blake160SighashAll),
+							HashType: types.HashTypeType,
+							Args:     blake2b.Blake160(secpContext.Key.PubKey()),
+						},
+						GroupType:    types.ScriptTypeLock,
+						InputIndices: []uint32{0},
+					},
+					{Script: joyidLock(k1Key), GroupType: types.ScriptTypeLock, InputIndices: []uint32{1}},
+					{Script: joyidLock(r1Key), GroupType: types.ScriptTypeLock, InputIndices: []uint32{2}},
+				},
+			}
+			tx.TxView.Witnesses[0] = (&types.WitnessArgs{Lock: make([]byte, 65)}).Serialize()
+			joyidWitnesses := [][]byte{tx.TxView.Witnesses[1], tx.TxView.Witnesses[2]}
+
+			signed, err := signTransaction(tx, contexts...)
+			if err != nil {
+				t.Fatalf("%s: SignTransaction() error = %v", name, err)
+			}
+			if want := []int{0, 1, 2}; !reflect.DeepEqual(signed, want) {
+				t.Errorf("%s: SignTransaction() signed = %v, want %v", name, signed, want)
+			}
+
+			if reflect.DeepEqual(joyidWitnesses, [][]byte{tx.TxView.Witnesses[1], tx.TxView.Witnesses[2]}) {
+				t.Errorf("%s: JoyID witnesses should be signed", name)
+			}
+			group := tx.ScriptGroups[1]
+			if got, want := recoverK1PubkeyHash(t, tx.TxView, group), k1Key.PubkeyHash(); string(got) != string(want) {
+				t.Errorf("%s: recovered pubkey hash = %x, want %x", name, got, want)
+			}
+
+			witnessArgs, err := types.DeserializeWitnessArgs(tx.TxView.Witnesses[2])
+			if err != nil {
+				t.Fatal(err)
+			}
+			lock := witnessArgs.Lock
+			if lock[0] != byte(NativeUnlock) {
+				t.Errorf("%s: unlock mode = %d, want %d", name, lock[0], NativeUnlock)
+			}
+			authDataLen := 37
+			signData := append([]byte{}, lock[129:129+authDataLen]...)
+			signData = append(signData, sha256.Sha256(lock[129+authDataLen:])...)
+			pubkey, _ := secp256r1.ImportKey(r1Key.PrivKey).Pubkey()
+			r, s := new(big.Int).SetBytes(lock[65:97]), new(big.Int).SetBytes(lock[97:129])
+			if !ecdsa.Verify(pubkey, sha256.Sha256(signData), r, s) {
+				t.Errorf("%s: secp256r1 signature of JoyID witness is invalid", name)
+			}
+		}
+	}
+}
+
+func TestJoyIDScriptSignerSkipsMismatchedLock(t *testing.T) {
+	k1Key := AlgPrivKey{"0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1", alg.Secp256k1}
+	otherKey := AlgPrivKey{"0x2262cd6c965d0065f93fb1fce03444e7f2a354b215b16dc44fe88a7246b6213b", alg.Secp256k1}
+	tx := testTransaction(1)
+	group := &transaction.ScriptGroup{
+		Script:       joyidaddress.DefaultJoyIDLock().FromPubkeyHash(otherKey.PubkeyHash(), alg.Secp256k1).Script,
+		GroupType:    types.ScriptTypeLock,
+		InputIndices: []uint32{0},
+	}
+	signer := &JoyIDScriptSigner{}
+	signed, err := signer.SignTransaction(tx, group, NewJoyIDContext(&JoyIDSignerConfig{Signer: k1Key.Signer(nil), Mode: NativeUnlock}))
+	if err != nil || signed {
+		t.Errorf("SignTransaction() = %t, %v, want false, nil", signed, err)
+	}
+	signed, err = signer.SignTransaction(tx, group, NewJoyIDContext(&JoyIDSignerConfig{Signer: k1Key.Signer(nil), Mode: SubkeyUnlock, Lock: group.Script}))
+	if err != nil || !signed {
+		t.Errorf("SignTransaction() = %t, %v, want true, nil", signed, err)
+	}
+}
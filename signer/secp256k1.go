@@ -1,53 +1,25 @@
 package signer
 
 import (
-	"encoding/binary"
-	"errors"
-
 	"github.com/nervina-labs/joyid-sdk-go/crypto/keccak"
-	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
 )
 
-const (
-	// unlock_mode + pubkey + signature
-	secp256k1EmptyWitnessLockLen = 86
-)
-
-func signSecp256k1Tx(tx *types.Transaction, key *secp256k1.Key, mode byte) error {
-	buf := tx.ComputeHash().Bytes()
-	witnesses := tx.Witnesses
-	if len(witnesses) < 1 {
-		return errors.New("first witness cannot be empty")
-	}
-	firstWitnessArgs, err := types.DeserializeWitnessArgs(witnesses[0])
+// Secp256k1SigningDigest returns the ethereum personal hash of the keccak256 sighash of the JoyID lock
+// script group, which is signed by the secp256k1 key
+func Secp256k1SigningDigest(tx *types.Transaction, group *transaction.ScriptGroup) ([]byte, error) {
+	msg, _, err := groupSigningMessage(tx, group, witness.Secp256k1LockLen)
 	if err != nil {
-		return errors.New("first witness must be WitnessArgs")
-	}
-	emptyWitness := types.WitnessArgs{
-		Lock:       make([]byte, secp256k1EmptyWitnessLockLen),
-		InputType:  firstWitnessArgs.InputType,
-		OutputType: firstWitnessArgs.OutputType,
-	}
-	emptyWitnessBytes := emptyWitness.Serialize()
-
-	bytesLen := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bytesLen, uint64(len(emptyWitnessBytes)))
-	buf = append(buf, bytesLen...)
-	buf = append(buf, emptyWitnessBytes...)
-
-	for i := 1; i < len(witnesses); i++ {
-		bytes := tx.Witnesses[i]
-		bytesLen := make([]byte, 8)
-		binary.LittleEndian.PutUint64(bytesLen, uint64(len(bytes)))
-		buf = append(buf, bytesLen...)
-		buf = append(buf, bytes...)
+		return nil, err
 	}
+	return personalDigest(msg), nil
+}
 
-	sighash := keccak.Keccak256(buf)
-	if err != nil {
-		return err
-	}
+// personalDigest returns the ethereum personal hash of keccak256 of the message
+func personalDigest(msg []byte) []byte {
+	sighash := keccak.Keccak256(msg)
 
 	// personal hash, ethereum prefix  \u0019Ethereum Signed Message:\n32
 	personalEthereumSignPrefix := [...]byte{
@@ -56,15 +28,29 @@ func signSecp256k1Tx(tx *types.Transaction, key *secp256k1.Key, mode byte) error
 	}
 	message := personalEthereumSignPrefix[:]
 	message = append(message, sighash...)
-	messageHash := keccak.Keccak256(message)
+	return keccak.Keccak256(message)
+}
 
-	signature := key.Sign(messageHash)
-	pubkeyHash := key.PubkeyHash()
+func signSecp256k1Tx(tx *types.Transaction, group *transaction.ScriptGroup, signer Secp256k1Signer, mode UnlockMode) error {
+	messageHash, err := Secp256k1SigningDigest(tx, group)
+	if err != nil {
+		return err
+	}
+	firstWitnessArgs, err := groupWitnessArgs(tx, group)
+	if err != nil {
+		return err
+	}
 
-	witnessArgsLock := []byte{mode}
-	witnessArgsLock = append(witnessArgsLock, pubkeyHash...)
-	witnessArgsLock = append(witnessArgsLock, signature...)
-	firstWitnessArgs.Lock = witnessArgsLock
-	tx.Witnesses[0] = firstWitnessArgs.Serialize()
+	signature, err := signer.Sign(messageHash)
+	if err != nil {
+		return err
+	}
+	lock := &witness.Secp256k1Lock{
+		Mode:       mode,
+		PubkeyHash: signer.PubkeyHash(),
+		Signature:  signature,
+	}
+	firstWitnessArgs.Lock = lock.Serialize()
+	setGroupWitnessArgs(tx, group, firstWitnessArgs)
 	return nil
 }
@@ -2,98 +2,119 @@ package signer
 
 import (
 	"encoding/base64"
-	"encoding/binary"
 	"errors"
+	"strconv"
 
-	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
-	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
 	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/webauthn"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
 )
 
 const (
-	secp256r1EmptyWitnessLockLen = 129 // unlock_mode + pubkey + signature
+	// the length of {"type":"webauthn.get","challenge":"","origin":"","crossOrigin":}
+	clientDataTemplateLen = 65
+	// the length of base64url encoded challenge
+	webAuthnChallengeLen = 86
 )
 
+// WebAuthnMsg is the hex of authenticatorData and clientDataJSON of WebAuthn
 type WebAuthnMsg struct {
 	AuthData   string
 	ClientData string
 }
 
-func GenerateWebAuthnChallenge(tx *types.Transaction) (string, error) {
-	txHash := tx.ComputeHash()
-	msg := txHash.Bytes()
-
-	witnesses := tx.Witnesses
-	if len(witnesses) < 1 {
-		return "", errors.New("first witness cannot be empty")
+// Parse decodes the hex of authData and clientData, and parses them
+func (m *WebAuthnMsg) Parse() (*webauthn.AuthData, *webauthn.ClientData, error) {
+	authData, err := utils.HexToBytes(m.AuthData)
+	if err != nil {
+		return nil, nil, errors.New("hex convert error")
 	}
-	firstWitnessArgs, err := types.DeserializeWitnessArgs(witnesses[0])
+	clientData, err := utils.HexToBytes(m.ClientData)
 	if err != nil {
-		return "", errors.New("first witness must be WitnessArgs")
+		return nil, nil, errors.New("hex convert error")
 	}
-	emptyWitness := types.WitnessArgs{
-		Lock:       make([]byte, secp256r1EmptyWitnessLockLen),
-		InputType:  firstWitnessArgs.InputType,
-		OutputType: firstWitnessArgs.OutputType,
+	parsedAuthData, err := webauthn.ParseAuthData(authData)
+	if err != nil {
+		return nil, nil, err
 	}
-	emptyWitnessBytes := emptyWitness.Serialize()
-
-	bytesLen := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bytesLen, uint64(len(emptyWitnessBytes)))
-	msg = append(msg, bytesLen...)
-	msg = append(msg, emptyWitnessBytes...)
+	parsedClientData, err := webauthn.ParseClientData(clientData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parsedAuthData, parsedClientData, nil
+}
 
-	for i := 1; i < len(witnesses); i++ {
-		bytes := tx.Witnesses[i]
-		bytesLen := make([]byte, 8)
-		binary.LittleEndian.PutUint64(bytesLen, uint64(len(bytes)))
-		msg = append(msg, bytesLen...)
-		msg = append(msg, bytes...)
+// GenerateWebAuthnChallenge returns the hex of the base64url challenge which is signed
+// by WebAuthn to unlock the inputs of the JoyID lock script group.
+func GenerateWebAuthnChallenge(tx *types.Transaction, group *transaction.ScriptGroup) (string, error) {
+	msg, _, err := groupSigningMessage(tx, group, witness.Secp256r1LockPrefixLen)
+	if err != nil {
+		return "", err
 	}
+	return webAuthnChallenge(msg), nil
+}
+
+// webAuthnChallenge returns the hex of base64url(hex(blake2b(msg)))
+func webAuthnChallenge(msg []byte) string {
 	msgHash := blake2b.Blake256(msg)
 	msgHashHex := utils.BytesToHex(msgHash)
 
+	challenge := make([]byte, webAuthnChallengeLen)
+	base64.RawURLEncoding.Encode(challenge, []byte(msgHashHex))
+	return utils.BytesToHex(challenge)
+}
+
+// ClientDataLen returns the length of clientData JSON of webauthn.get with the origin and crossOrigin
+func ClientDataLen(origin string, crossOrigin bool) int {
+	length := clientDataTemplateLen + webAuthnChallengeLen + len(strconv.FormatBool(crossOrigin))
+	encoded, err := webauthn.CCDToString(origin)
 	if err != nil {
-		return "", err
+		// the origin of invalid UTF-8 cannot be signed, and its length is only an estimate
+		return length + len(origin)
 	}
-	challenge := make([]byte, 86)
-	base64.RawURLEncoding.Encode(challenge, []byte(msgHashHex))
-	return utils.BytesToHex(challenge), nil
+	// the quotes of the string are excluded
+	return length + len(encoded) - 2
 }
 
-func signSecp256r1Tx(tx *types.Transaction, key *secp256r1.Key, mode byte, webAuthn *WebAuthnMsg) error {
+// WebAuthnMsgLen returns the total length of authData and clientData of WebAuthn which is
+// appended to the secp256r1 witness lock
+func WebAuthnMsgLen(authDataLen int, origin string, crossOrigin bool) int {
+	return authDataLen + ClientDataLen(origin, crossOrigin)
+}
+
+func signSecp256r1Tx(tx *types.Transaction, group *transaction.ScriptGroup, signer Secp256r1Signer, mode UnlockMode) error {
+	challenge, err := GenerateWebAuthnChallenge(tx, group)
+	if err != nil {
+		return err
+	}
+	webAuthn, signature, err := signer.SignWebAuthn(challenge)
+	if err != nil {
+		return err
+	}
 	clientDataBytes, err := utils.HexToBytes(webAuthn.ClientData)
 	if err != nil {
 		return errors.New("hex convert error")
 	}
-	clientDataHash := sha256.Sha256(clientDataBytes)
 	authData, err := utils.HexToBytes(webAuthn.AuthData)
 	if err != nil {
 		return errors.New("hex convert error")
 	}
-	signData := authData
-	signData = append(signData, clientDataHash...)
-	signature := key.Sign(sha256.Sha256(signData))
-	_, pubkey := key.Pubkey()
 
-	if len(tx.Witnesses) < 1 {
-		return errors.New("first witness cannot be empty")
-	}
-	firstWitnessArgs, err := types.DeserializeWitnessArgs(tx.Witnesses[0])
+	firstWitnessArgs, err := groupWitnessArgs(tx, group)
 	if err != nil {
-		return errors.New("first witness must be WitnessArgs")
+		return err
 	}
-	witnessArgsLock := []byte{mode}
-	witnessArgsLock = append(witnessArgsLock, pubkey...)
-	witnessArgsLock = append(witnessArgsLock, signature...)
-	witnessArgsLock = append(witnessArgsLock, authData...)
-	witnessArgsLock = append(witnessArgsLock, clientDataBytes...)
-	firstWitnessArgs.Lock = witnessArgsLock
-	if err != nil {
-		return errors.New("hex convert error")
+	lock := &witness.Secp256r1Lock{
+		Mode:       mode,
+		Pubkey:     signer.Pubkey(),
+		Signature:  signature,
+		AuthData:   authData,
+		ClientData: clientDataBytes,
 	}
-	tx.Witnesses[0] = firstWitnessArgs.Serialize()
+	firstWitnessArgs.Lock = lock.Serialize()
+	setGroupWitnessArgs(tx, group, firstWitnessArgs)
 	return nil
 }
@@ -1,72 +1,107 @@
 package signer
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/nervina-labs/joyid-sdk-go/aggregator"
 	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
-	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
-	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
 	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
 	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
 )
 
+// UnlockMode is the first byte of the JoyID witness lock
+type UnlockMode = witness.UnlockMode
+
 const (
-	native byte = 1
-	subkey byte = 2
+	NativeUnlock = witness.NativeUnlock
+	SubkeyUnlock = witness.SubkeyUnlock
 )
 
-type AlgPrivKey struct {
-	PrivKey string
-	Alg     alg.AlgIndex
+// Signer is the key of a JoyID account, and the private key may be held in memory, KMS/HSM
+// or the passkey of the user which never leaves the authenticator
+type Signer interface {
+	Alg() alg.AlgIndex
+	// Pubkey returns the 64-byte uncompressed pubkey without the 0x04 prefix
+	Pubkey() []byte
+	// PubkeyHash returns blake160 of secp256r1 pubkey or keccak160 of secp256k1 pubkey
+	PubkeyHash() []byte
 }
 
-func SignNativeUnlockTx(tx *types.Transaction, algKey AlgPrivKey, webAuthn *WebAuthnMsg) error {
-	if algKey.Alg == alg.Secp256r1 {
-		key := secp256r1.ImportKey(algKey.PrivKey)
-		return signSecp256r1Tx(tx, key, native, webAuthn)
-	}
-	key := secp256k1.ImportKey(algKey.PrivKey)
-	return signSecp256k1Tx(tx, key, native)
+// Secp256k1Signer signs the digest with the secp256k1 key
+type Secp256k1Signer interface {
+	Signer
+	// Sign returns the 65-byte recoverable signature of the 32-byte digest
+	Sign(digest []byte) ([]byte, error)
 }
 
-func SignSubkeyUnlockTx(tx *types.Transaction, algKey AlgPrivKey, webAuthn *WebAuthnMsg) error {
-	if algKey.Alg == alg.Secp256r1 {
-		key := secp256r1.ImportKey(algKey.PrivKey)
-		return signSecp256r1Tx(tx, key, subkey, webAuthn)
-	}
-	key := secp256k1.ImportKey(algKey.PrivKey)
-	return signSecp256k1Tx(tx, key, subkey)
+// Secp256r1Signer signs the challenge through WebAuthn with the secp256r1 key
+type Secp256r1Signer interface {
+	Signer
+	// SignWebAuthn returns the WebAuthn message of the challenge and the 64-byte r|s signature
+	// of sha256(authData | sha256(clientData))
+	SignWebAuthn(challenge string) (*WebAuthnMsg, []byte, error)
+}
+
+// SignNativeUnlockTx signs the inputs of the JoyID lock script group with the main key of the JoyID account
+func SignNativeUnlockTx(tx *types.Transaction, group *transaction.ScriptGroup, signer Signer) error {
+	return signTx(tx, group, signer, NativeUnlock)
+}
+
+// SignSubkeyUnlockTx signs the inputs of the JoyID lock script group with a subkey of the JoyID account
+func SignSubkeyUnlockTx(tx *types.Transaction, group *transaction.ScriptGroup, signer Signer) error {
+	return signTx(tx, group, signer, SubkeyUnlock)
 }
 
-func BuildOutputTypeWithSubkeySmt(tx *types.Transaction, algKey AlgPrivKey, addr *address.Address, indexerUrl string) error {
-	var pubkeyHash []byte
-	if algKey.Alg == alg.Secp256k1 {
-		pubkeyHash = secp256k1.ImportKey(algKey.PrivKey).PubkeyHash()
-	} else {
-		pubkeyHash = secp256r1.ImportKey(algKey.PrivKey).PubkeyHash()
+func signTx(tx *types.Transaction, group *transaction.ScriptGroup, signer Signer, mode UnlockMode) error {
+	if signer == nil {
+		return errors.New("signer cannot be empty")
+	}
+	switch signer.Alg() {
+	case alg.Secp256r1:
+		if s, ok := signer.(Secp256r1Signer); ok {
+			return signSecp256r1Tx(tx, group, s, mode)
+		}
+	case alg.Secp256k1:
+		if s, ok := signer.(Secp256k1Signer); ok {
+			return signSecp256k1Tx(tx, group, s, mode)
+		}
 	}
+	return fmt.Errorf("signer doesn't support alg %d", signer.Alg())
+}
+
+// BuildOutputTypeWithSubkeySmt puts the subkey unlock smt entry into WitnessArgs.OutputType
+// of the first witness of the JoyID lock script group
+func BuildOutputTypeWithSubkeySmt(tx *types.Transaction, group *transaction.ScriptGroup, subkey Signer, addr *address.Address, aggregatorUrl string) error {
+	return BuildOutputTypeWithSubkeySmtContext(context.Background(), tx, group, subkey, addr, aggregator.NewRPCClient(aggregatorUrl))
+}
 
-	rpc := aggregator.NewRPCClient(indexerUrl)
-	unlockSmt, err := rpc.GetSubkeyUnlockSmt(addr, pubkeyHash, algKey.Alg)
+// BuildOutputTypeWithSubkeySmtContext is the same as BuildOutputTypeWithSubkeySmt with the context
+// and the aggregator client
+func BuildOutputTypeWithSubkeySmtContext(ctx context.Context, tx *types.Transaction, group *transaction.ScriptGroup, subkey Signer, addr *address.Address, rpc *aggregator.RPCClient) error {
+	if subkey == nil {
+		return errors.New("subkey cannot be empty")
+	}
+	if addr == nil || rpc == nil {
+		return errors.New("address and aggregator cannot be empty")
+	}
+	unlockSmt, err := rpc.GetSubkeyUnlockSmtContext(ctx, addr, subkey.PubkeyHash(), subkey.Alg())
 	if err != nil {
 		return err
 	}
-	witnesses := tx.Witnesses
-	if len(witnesses) < 1 {
-		return errors.New("first witness cannot be empty")
-	}
-	firstWitnessArgs, err := types.DeserializeWitnessArgs(witnesses[0])
+	firstWitnessArgs, err := groupWitnessArgs(tx, group)
 	if err != nil {
-		return errors.New("first witness must be WitnessArgs")
+		return err
 	}
 	unlockBytes, err := utils.HexToBytes(unlockSmt)
-
 	if err != nil {
 		return errors.New("hex convert error")
 	}
 	firstWitnessArgs.OutputType = unlockBytes
-	tx.Witnesses[0] = firstWitnessArgs.Serialize()
+	setGroupWitnessArgs(tx, group, firstWitnessArgs)
 	return nil
 }
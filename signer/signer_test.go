@@ -0,0 +1,279 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/keccak"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/webauthn"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func testTransaction(inputsCount int) *types.Transaction {
+	tx := &types.Transaction{
+		Version:     0,
+		CellDeps:    []*types.CellDep{},
+		HeaderDeps:  []types.Hash{},
+		Outputs:     []*types.CellOutput{},
+		OutputsData: [][]byte{},
+	}
+	for i := 0; i < inputsCount; i++ {
+		tx.Inputs = append(tx.Inputs, &types.CellInput{
+			PreviousOutput: &types.OutPoint{
+				TxHash: types.HexToHash("0x68777db22145ce8e55014cbfd0d52e7357068451ea539ac7df952a36a9696f02"),
+				Index:  uint32(i),
+			},
+		})
+		tx.Witnesses = append(tx.Witnesses, []byte{})
+	}
+	return tx
+}
+
+func recoverK1PubkeyHash(t *testing.T, tx *types.Transaction, group *transaction.ScriptGroup) []byte {
+	msg, _, err := groupSigningMessage(tx, group, witness.Secp256k1LockLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("\x19Ethereum Signed Message:\n32")
+	message = append(message, keccak.Keccak256(msg)...)
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.Witnesses[group.InputIndices[0]])
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := secp256k1.GenerateKey()
+	pubkey := key.RecoverPubkey(keccak.Keccak256(message), witnessArgs.Lock[21:])
+	if len(pubkey) != 65 {
+		t.Fatalf("RecoverPubkey() failed")
+	}
+	return keccak.Keccak160(pubkey[1:])
+}
+
+func TestSignNativeUnlockTxWithScriptGroups(t *testing.T) {
+	keyA := "0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1"
+	keyB := "0x2262cd6c965d0065f93fb1fce03444e7f2a354b215b16dc44fe88a7246b6213b"
+	tx := testTransaction(4)
+	// witness beyond the inputs count is covered by the sighash of every group
+	tx.Witnesses = append(tx.Witnesses, []byte{0x01, 0x02})
+	groupA := &transaction.ScriptGroup{GroupType: types.ScriptTypeLock, InputIndices: []uint32{1, 3}}
+	groupB := &transaction.ScriptGroup{GroupType: types.ScriptTypeLock, InputIndices: []uint32{2}}
+
+	if err := SignNativeUnlockTx(tx, groupA, NewSecp256k1KeySigner(keyA)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SignNativeUnlockTx(tx, groupB, NewSecp256k1KeySigner(keyB)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.Witnesses[0]) != 0 || len(tx.Witnesses[3]) != 0 {
+		t.Errorf("witnesses out of the group first input should not be changed")
+	}
+	for _, tc := range []struct {
+		key   string
+		group *transaction.ScriptGroup
+	}{{keyA, groupA}, {keyB, groupB}} {
+		want := secp256k1.ImportKey(tc.key).PubkeyHash()
+		if got := recoverK1PubkeyHash(t, tx, tc.group); !bytes.Equal(got, want) {
+			t.Errorf("recovered pubkey hash = %x, want %x", got, want)
+		}
+	}
+}
+
+func TestGenerateWebAuthnChallengeWithScriptGroups(t *testing.T) {
+	tx := testTransaction(2)
+	challenge0, err := GenerateWebAuthnChallenge(tx, &transaction.ScriptGroup{InputIndices: []uint32{0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge1, err := GenerateWebAuthnChallenge(tx, &transaction.ScriptGroup{InputIndices: []uint32{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge0 != challenge1 {
+		t.Errorf("challenges of single input groups with the same witnesses should be equal")
+	}
+
+	tx.Witnesses[1] = (&types.WitnessArgs{OutputType: []byte{0x01}}).Serialize()
+	challenge1, _ = GenerateWebAuthnChallenge(tx, &transaction.ScriptGroup{InputIndices: []uint32{1}})
+	if challenge0 == challenge1 {
+		t.Errorf("challenge should commit to the first witness of the group")
+	}
+
+	if _, err := GenerateWebAuthnChallenge(tx, &transaction.ScriptGroup{InputIndices: []uint32{2}}); err == nil {
+		t.Errorf("GenerateWebAuthnChallenge() should fail with out of range input index")
+	}
+}
+
+func TestClientDataLen(t *testing.T) {
+	tx := testTransaction(1)
+	group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+	challenge, _ := GenerateWebAuthnChallenge(tx, group)
+	webAuthn, _ := testWebAuthnMsg(challenge)
+	clientData, _ := utils.HexToBytes(webAuthn.ClientData)
+	if got, want := ClientDataLen("http://localhost:8000", false), len(clientData); got != want {
+		t.Errorf("ClientDataLen() = %d, want %d", got, want)
+	}
+	if got, want := WebAuthnMsgLen(37, "https://app.joy.id", false), 37+174; got != want {
+		t.Errorf("WebAuthnMsgLen() = %d, want %d", got, want)
+	}
+	// "crossOrigin":true is one byte shorter than false
+	if got, want := WebAuthnMsgLen(37, "https://app.joy.id", true), 37+173; got != want {
+		t.Errorf("WebAuthnMsgLen() = %d, want %d", got, want)
+	}
+	// the quote in origin is escaped in JSON
+	if got, want := ClientDataLen(`https://a"b`, false), 70+86+12; got != want {
+		t.Errorf("ClientDataLen() = %d, want %d", got, want)
+	}
+	// the control character is escaped as \u000a and the non-ASCII characters are kept as UTF-8
+	if got, want := ClientDataLen("https://例\n\u2028", false), 70+86+8+3+6+3; got != want {
+		t.Errorf("ClientDataLen() = %d, want %d", got, want)
+	}
+}
+
+// remoteSigner mocks a signer whose private key is held out of the SDK
+type remoteSigner struct {
+	*Secp256k1KeySigner
+	alg alg.AlgIndex
+}
+
+func (s *remoteSigner) Alg() alg.AlgIndex {
+	return s.alg
+}
+
+func TestSignWithExternalSigner(t *testing.T) {
+	key := "0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1"
+	tx := testTransaction(1)
+	group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+	if err := SignSubkeyUnlockTx(tx, group, &remoteSigner{NewSecp256k1KeySigner(key), alg.Secp256k1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := recoverK1PubkeyHash(t, tx, group), secp256k1.ImportKey(key).PubkeyHash(); !bytes.Equal(got, want) {
+		t.Errorf("recovered pubkey hash = %x, want %x", got, want)
+	}
+
+	// the signer of secp256r1 must be able to sign WebAuthn
+	if err := SignSubkeyUnlockTx(tx, group, &remoteSigner{NewSecp256k1KeySigner(key), alg.Secp256r1}); err == nil {
+		t.Errorf("SignSubkeyUnlockTx() should fail with mismatched alg")
+	}
+}
+
+// dualSigner implements both Secp256k1Signer and Secp256r1Signer and is dispatched by its alg
+type dualSigner struct {
+	*Secp256k1KeySigner
+}
+
+func (s *dualSigner) SignWebAuthn(challenge string) (*WebAuthnMsg, []byte, error) {
+	return nil, nil, errors.New("dualSigner doesn't sign WebAuthn")
+}
+
+func TestSignWithAlgOfSigner(t *testing.T) {
+	key := "0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1"
+	tx := testTransaction(1)
+	group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+	if err := SignNativeUnlockTx(tx, group, &dualSigner{NewSecp256k1KeySigner(key)}); err != nil {
+		t.Fatalf("SignNativeUnlockTx() error = %v", err)
+	}
+	if got, want := recoverK1PubkeyHash(t, tx, group), secp256k1.ImportKey(key).PubkeyHash(); !bytes.Equal(got, want) {
+		t.Errorf("recovered pubkey hash = %x, want %x", got, want)
+	}
+	if err := SignNativeUnlockTx(tx, group, nil); err == nil {
+		t.Errorf("SignNativeUnlockTx() should fail with nil signer")
+	}
+	if err := BuildOutputTypeWithSubkeySmt(tx, group, nil, nil, "http://localhost:8090"); err == nil {
+		t.Errorf("BuildOutputTypeWithSubkeySmt() should fail with nil subkey")
+	}
+}
+
+func TestAlgPrivKeyImport(t *testing.T) {
+	for _, key := range []AlgPrivKey{
+		{"0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1", alg.Secp256k1},
+		{"0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761", alg.Secp256r1},
+	} {
+		s, err := key.Import(nil)
+		if err != nil {
+			t.Fatalf("Import() error = %v", err)
+		}
+		if !bytes.Equal(s.PubkeyHash(), key.PubkeyHash()) || s.Alg() != key.Alg {
+			t.Errorf("Import() pubkey hash = %x, want %x", s.PubkeyHash(), key.PubkeyHash())
+		}
+		key.PrivKey = "0xnot a key"
+		if _, err := key.Import(nil); err == nil {
+			t.Errorf("Import() should fail with invalid key")
+		}
+	}
+	if _, err := NewSecp256k1KeySigner("0xnot a key").Sign(make([]byte, 32)); err == nil {
+		t.Errorf("Sign() should fail with invalid key")
+	}
+}
+
+func TestInvalidKeySigner(t *testing.T) {
+	k1 := NewSecp256k1KeySigner("zz-not-hex")
+	if k1.Pubkey() != nil || k1.PubkeyHash() != nil {
+		t.Errorf("Pubkey() and PubkeyHash() should be nil with invalid key")
+	}
+	if _, err := k1.Sign(make([]byte, 32)); err == nil {
+		t.Errorf("Sign() should fail with invalid key")
+	}
+	r1 := NewSecp256r1KeySigner("zz-not-hex", testWebAuthnMsg)
+	if r1.Pubkey() != nil || r1.PubkeyHash() != nil {
+		t.Errorf("Pubkey() and PubkeyHash() should be nil with invalid key")
+	}
+	if _, _, err := r1.SignWebAuthn("00"); err == nil {
+		t.Errorf("SignWebAuthn() should fail with invalid key")
+	}
+	authenticatorSigner := NewAuthenticatorSigner(webauthn.NewAuthenticator(secp256r1.ImportKey("zz"), "localhost", "http://localhost:8000"))
+	if authenticatorSigner.Pubkey() != nil || authenticatorSigner.PubkeyHash() != nil {
+		t.Errorf("Pubkey() and PubkeyHash() of the authenticator should be nil with invalid key")
+	}
+	if _, _, err := authenticatorSigner.SignWebAuthn("00"); err == nil {
+		t.Errorf("SignWebAuthn() of the authenticator should fail with invalid key")
+	}
+	if _, err := NewSecp256k1KeySignerWithKey(nil).Sign(make([]byte, 32)); err == nil {
+		t.Errorf("Sign() should fail with nil key")
+	}
+	for _, algIndex := range []alg.AlgIndex{alg.Secp256k1, alg.Secp256r1} {
+		key := AlgPrivKey{PrivKey: "zz-not-hex", Alg: algIndex}
+		if key.PubkeyHash() != nil {
+			t.Errorf("PubkeyHash() should be nil with invalid key")
+		}
+		group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+		if err := SignNativeUnlockTx(testTransaction(1), group, key.Signer(testWebAuthnMsg)); err == nil {
+			t.Errorf("SignNativeUnlockTx() should fail with invalid key")
+		}
+	}
+}
+
+func TestSignSecp256r1TxDeterministic(t *testing.T) {
+	key, _ := secp256r1.ImportKeyHex("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	key.Deterministic = true
+	// the golden witness of the native unlock
+	want := "0x" +
+		// WitnessArgs header and lock length
+		"6b010000100000006b0100006b01000057010000" +
+		// mode
+		"01" +
+		// pubkey
+		"4599a5795423d54ab8e1f44f5c6ef5be9b1829beddb787bc732e4469d25f8c93e94afa393617f905bf1765c35dc38501a862b4b2f794a88b4f9010da02411a85" +
+		// signature with low S
+		"5bddeea96512acb42794e93508533b80ca9f1fbc523b47d1988ae719c44cc03b04f03f70a41bada099eae5966be3bfae5789bca3adca5324587051978bf05625" +
+		// authData
+		"49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630162f9fb77" +
+		// clientData
+		"7b2274797065223a22776562617574686e2e676574222c226368616c6c656e6765223a224e544d314d5445784d545533596d49324e7a646a596a457a5954646b595751775a574d30595746684d7a6b355954466d5a6d45774e6a466b4e6a646d4d6a597a4e4445784d544a6d4f5759784e57566d4e446c6c4d41222c226f726967696e223a22687474703a2f2f6c6f63616c686f73743a38303030222c2263726f73734f726967696e223a66616c73657d"
+	for i := 0; i < 2; i++ {
+		tx := testTransaction(1)
+		group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+		if err := signSecp256r1Tx(tx, group, NewSecp256r1KeySignerWithKey(key, testWebAuthnMsg), NativeUnlock); err != nil {
+			t.Fatal(err)
+		}
+		if got := utils.BytesTo0xHex(tx.Witnesses[0]); got != want {
+			t.Errorf("witness = %s, want %s", got, want)
+		}
+	}
+}
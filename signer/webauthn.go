@@ -0,0 +1,80 @@
+package signer
+
+import (
+	"errors"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/webauthn"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// WebAuthnAssertion is the assertion returned by navigator.credentials.get in the browser, and it
+// implements Secp256r1Signer to assemble the JoyID witness lock without the private key
+type WebAuthnAssertion struct {
+	AuthenticatorData []byte
+	ClientDataJSON    []byte
+	// Signature is the ASN.1 DER signature of authenticatorData | sha256(clientDataJSON)
+	Signature []byte
+	// CredentialPubkey is the 64-byte pubkey or the 65-byte uncompressed pubkey of the credential
+	CredentialPubkey []byte
+	// Options checks the RP ID, origin and user verification of the assertion if it isn't nil
+	Options *webauthn.VerifyOptions
+}
+
+// SignWebAuthnAssertionTx writes the JoyID secp256r1 witness lock of the script group with the assertion
+// which was produced by the passkey of the user for the challenge of GenerateWebAuthnChallenge
+func SignWebAuthnAssertionTx(tx *types.Transaction, group *transaction.ScriptGroup, assertion *WebAuthnAssertion, mode UnlockMode) error {
+	return signTx(tx, group, assertion, mode)
+}
+
+func (a *WebAuthnAssertion) Alg() alg.AlgIndex {
+	return alg.Secp256r1
+}
+
+func (a *WebAuthnAssertion) Pubkey() []byte {
+	if len(a.CredentialPubkey) == 65 && a.CredentialPubkey[0] == 0x04 {
+		return a.CredentialPubkey[1:]
+	}
+	return a.CredentialPubkey
+}
+
+func (a *WebAuthnAssertion) PubkeyHash() []byte {
+	return blake2b.Blake160(a.Pubkey())
+}
+
+// SignWebAuthn checks authData, clientDataJSON and the signature of the assertion, and it returns
+// the 64-byte r|s signature with low S which is expected by JoyID lock
+func (a *WebAuthnAssertion) SignWebAuthn(challenge string) (*WebAuthnMsg, []byte, error) {
+	pubkey := a.Pubkey()
+	if len(pubkey) != 64 {
+		return nil, nil, errors.New("credential pubkey must be 64-byte or 65-byte uncompressed")
+	}
+	expectedChallenge, err := utils.HexToBytes(challenge)
+	if err != nil {
+		return nil, nil, errors.New("hex convert error")
+	}
+	if _, _, err := a.Options.Verify(a.AuthenticatorData, a.ClientDataJSON, string(expectedChallenge)); err != nil {
+		return nil, nil, err
+	}
+
+	signature, err := secp256r1.ParseDERSignature(a.Signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	signData := append([]byte{}, a.AuthenticatorData...)
+	signData = append(signData, sha256.Sha256(a.ClientDataJSON)...)
+	if !secp256r1.Verify(pubkey, sha256.Sha256(signData), signature) {
+		return nil, nil, errors.New("invalid signature of the WebAuthn assertion")
+	}
+
+	webAuthn := &WebAuthnMsg{
+		AuthData:   utils.BytesToHex(a.AuthenticatorData),
+		ClientData: utils.BytesToHex(a.ClientDataJSON),
+	}
+	return webAuthn, signature, nil
+}
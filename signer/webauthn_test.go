@@ -0,0 +1,157 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/webauthn"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func testAssertion(t *testing.T, key *secp256r1.Key, challenge string, highS bool) *WebAuthnAssertion {
+	authData, _ := utils.HexToBytes("49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630162f9fb77")
+	clientData := []byte(fmt.Sprintf(`{"type":"webauthn.get","challenge":"%s","origin":"http://localhost:8000","crossOrigin":false}`, challenge))
+	signData := append([]byte{}, authData...)
+	signData = append(signData, sha256.Sha256(clientData)...)
+	r, s, err := ecdsa.Sign(rand.Reader, key.PrivateKey, sha256.Sha256(signData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := elliptic.P256().Params().N
+	if highS == (s.Cmp(new(big.Int).Rsh(n, 1)) <= 0) {
+		s = new(big.Int).Sub(n, s)
+	}
+	der, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	_, pubkey := key.Pubkey()
+	return &WebAuthnAssertion{
+		AuthenticatorData: authData,
+		ClientDataJSON:    clientData,
+		Signature:         der,
+		CredentialPubkey:  append([]byte{0x04}, pubkey...),
+	}
+}
+
+func TestSignWebAuthnAssertionTx(t *testing.T) {
+	key := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	tx := testTransaction(2)
+	group := &transaction.ScriptGroup{InputIndices: []uint32{1}}
+	challengeHex, _ := GenerateWebAuthnChallenge(tx, group)
+	challenge, _ := utils.HexToBytes(challengeHex)
+
+	assertion := testAssertion(t, key, string(challenge), true)
+	if err := SignWebAuthnAssertionTx(tx, group, assertion, NativeUnlock); err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.Witnesses[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := witnessArgs.Lock
+	if got, want := len(lock), 129+len(assertion.AuthenticatorData)+len(assertion.ClientDataJSON); got != want {
+		t.Fatalf("witness lock length = %d, want %d", got, want)
+	}
+	_, pubkey := key.Pubkey()
+	if utils.BytesToHex(lock[1:65]) != utils.BytesToHex(pubkey) {
+		t.Errorf("witness lock pubkey = %x, want %x", lock[1:65], pubkey)
+	}
+	s := new(big.Int).SetBytes(lock[97:129])
+	if s.Cmp(new(big.Int).Rsh(elliptic.P256().Params().N, 1)) > 0 {
+		t.Errorf("signature of witness lock should be low S")
+	}
+}
+
+func TestSignWebAuthnAssertionTxWithWrongChallenge(t *testing.T) {
+	key := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	tx := testTransaction(1)
+	group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+	assertion := testAssertion(t, key, "wrong-challenge", false)
+	if err := SignWebAuthnAssertionTx(tx, group, assertion, NativeUnlock); err == nil {
+		t.Errorf("SignWebAuthnAssertionTx() should fail with wrong challenge")
+	}
+	if len(tx.Witnesses[0]) != 0 {
+		t.Errorf("witness should not be changed")
+	}
+}
+
+func TestSignWebAuthnAssertionTxWithOptions(t *testing.T) {
+	key := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	tx := testTransaction(1)
+	group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+	challengeHex, _ := GenerateWebAuthnChallenge(tx, group)
+	challenge, _ := utils.HexToBytes(challengeHex)
+
+	assertion := testAssertion(t, key, string(challenge), false)
+	assertion.Options = &webauthn.VerifyOptions{RPID: "localhost", Origins: []string{"https://app.joy.id"}}
+	if err := SignWebAuthnAssertionTx(tx, group, assertion, NativeUnlock); err == nil {
+		t.Errorf("SignWebAuthnAssertionTx() should fail with disallowed origin")
+	}
+	assertion.Options.Origins = append(assertion.Options.Origins, "http://localhost:8000")
+	if err := SignWebAuthnAssertionTx(tx, group, assertion, NativeUnlock); err != nil {
+		t.Errorf("SignWebAuthnAssertionTx() error = %v", err)
+	}
+}
+
+func TestAuthenticatorSigner(t *testing.T) {
+	key := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	authenticatorSigner := NewAuthenticatorSigner(webauthn.NewAuthenticator(key, "localhost", "http://localhost:8000"))
+	tx := testTransaction(1)
+	group := &transaction.ScriptGroup{InputIndices: []uint32{0}}
+	if err := SignNativeUnlockTx(tx, group, authenticatorSigner); err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := witnessArgs.Lock
+	authData, clientData := lock[129:129+webauthn.AuthDataMinLen], lock[129+webauthn.AuthDataMinLen:]
+	if got, want := len(clientData), ClientDataLen("http://localhost:8000", false); got != want {
+		t.Errorf("clientData length = %d, want %d", got, want)
+	}
+
+	msg, _, err := groupSigningMessage(testTransaction(1), group, witness.Secp256r1LockPrefixLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyWebAuthn(msg, lock[1:65], lock[65:129], authData, clientData); err != nil {
+		t.Errorf("verifyWebAuthn() error = %v", err)
+	}
+	if authenticatorSigner.Authenticator.SignCount() != 1 {
+		t.Errorf("SignCount() = %d, want 1", authenticatorSigner.Authenticator.SignCount())
+	}
+
+	authenticatorSigner.Authenticator.CrossOrigin = true
+	if err := SignNativeUnlockTx(tx, group, authenticatorSigner); err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, _ = types.DeserializeWitnessArgs(tx.Witnesses[0])
+	if got, want := len(witnessArgs.Lock)-129-webauthn.AuthDataMinLen, ClientDataLen("http://localhost:8000", true); got != want {
+		t.Errorf("clientData length = %d, want %d", got, want)
+	}
+}
+
+// verifyWebAuthn checks the WebAuthn message and the secp256r1 signature of the challenge of msg
+func verifyWebAuthn(msg, pubkey, signature, authData, clientData []byte) error {
+	challenge, _ := utils.HexToBytes(webAuthnChallenge(msg))
+	var options *webauthn.VerifyOptions
+	if _, _, err := options.Verify(authData, clientData, string(challenge)); err != nil {
+		return err
+	}
+	signData := append([]byte{}, authData...)
+	signData = append(signData, sha256.Sha256(clientData)...)
+	if !secp256r1.Verify(pubkey, sha256.Sha256(signData), signature) {
+		return errors.New("invalid secp256r1 signature of the WebAuthn message")
+	}
+	return nil
+}
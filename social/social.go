@@ -0,0 +1,73 @@
+package social
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/cota"
+	"github.com/nervina-labs/joyid-sdk-go/deployment"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/systemscript"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// Config is the guardians of the JoyID account, and Threshold of them are required to recover the account
+type Config struct {
+	// Guardians are the JoyID locks or the secp256k1_blake160 locks of the guardians
+	Guardians []*types.Script
+	Threshold uint8
+}
+
+func (c *Config) validate(network types.Network) error {
+	if c == nil || c.Threshold == 0 || int(c.Threshold) > len(c.Guardians) {
+		return errors.New("threshold should be between 1 and the number of guardians")
+	}
+	for _, guardian := range c.Guardians {
+		if !isGuardianLock(network, guardian) {
+			return errors.New("guardian lock must be the JoyID lock or the secp256k1_blake160 lock")
+		}
+	}
+	return nil
+}
+
+// isGuardianLock checks both the code hash and the hash type of the guardian lock
+func isGuardianLock(network types.Network, lock *types.Script) bool {
+	if lock == nil {
+		return false
+	}
+	if deployment.IsJoyIDLock(lock) {
+		return true
+	}
+	return lock.CodeHash == systemscript.GetCodeHash(network, systemscript.Secp256k1Blake160SighashAll) &&
+		lock.HashType == types.HashTypeType && len(lock.Args) == 20
+}
+
+// Builder builds the transactions which set the guardians of the JoyID account. Recovering the account
+// with the signatures of the guardians isn't supported, because the social unlock witness of the JoyID
+// lock isn't specified.
+type Builder struct {
+	Cota *cota.Builder
+}
+
+func NewBuilder(network types.Network, aggregatorUrl, indexerUrl string) *Builder {
+	return &Builder{Cota: cota.NewBuilder(network, aggregatorUrl, indexerUrl)}
+}
+
+// BuildSetupTx builds the transaction which adds the guardians into the CoTA extension of the JoyID address
+func (b *Builder) BuildSetupTx(ctx context.Context, addr *address.Address, config *Config, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	if err := config.validate(b.Cota.Network); err != nil {
+		return nil, err
+	}
+	return b.Cota.BuildSocialTx(ctx, addr, aggregator.ExtActionAddSocial, config.Threshold, config.Guardians, unlockCtx)
+}
+
+// BuildUpdateTx builds the transaction which replaces the guardians and the threshold of the JoyID address
+func (b *Builder) BuildUpdateTx(ctx context.Context, addr *address.Address, config *Config, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	if err := config.validate(b.Cota.Network); err != nil {
+		return nil, err
+	}
+	return b.Cota.BuildSocialTx(ctx, addr, aggregator.ExtActionUpdateSocial, config.Threshold, config.Guardians, unlockCtx)
+}
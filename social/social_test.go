@@ -0,0 +1,89 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/systemscript"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+var guardianKeys = []signer.AlgPrivKey{
+	{PrivKey: "0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1", Alg: alg.Secp256k1},
+	{PrivKey: "0x2262cd6c965d0065f93fb1fce03444e7f2a354b215b16dc44fe88a7246b6213b", Alg: alg.Secp256k1},
+	{PrivKey: "0x6c9ed03816e3111e49384b86ab3b6d7d1e6bcbe1d2d6c5f1e63a0d1a3c3b5a41", Alg: alg.Secp256k1},
+}
+
+func guardianLock(key signer.AlgPrivKey) *types.Script {
+	return joyidaddress.DefaultJoyIDLock().FromPubkeyHash(key.PubkeyHash(), key.Alg).Script
+}
+
+func TestBuildSetupTx(t *testing.T) {
+	node, server := testutil.NewNode(t)
+	defer server.Close()
+	node.Results["generate_extension_social_smt"] = `{"smt_root_hash":"` + utils.BytesTo0xHex(make([]byte, 32)) + `","extension_smt_entry":"0xaabb","block_number":1}`
+
+	b := NewBuilder(types.NetworkTest, server.URL, server.URL)
+	config := &Config{Threshold: 2}
+	for _, key := range guardianKeys {
+		config.Guardians = append(config.Guardians, guardianLock(key))
+	}
+	tx, err := b.BuildSetupTx(context.Background(), testutil.Address(), config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.TxView.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := utils.BytesToHex(witnessArgs.InputType); got != "f0aabb" {
+		t.Errorf("WitnessArgs.InputType = %s, want f0aabb", got)
+	}
+	var req aggregator.ExtensionSocialReq
+	if err := json.Unmarshal(node.Params["generate_extension_social_smt"], &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Must != 2 || req.Total != 3 || len(req.Signers) != 3 || req.Signers[1].String() != aggregator.LockScript(config.Guardians[1]).String() {
+		t.Errorf("social request = %+v", req)
+	}
+
+	config.Threshold = 4
+	if _, err := b.BuildUpdateTx(context.Background(), testutil.Address(), config, nil); err == nil {
+		t.Errorf("BuildUpdateTx() should fail with the threshold greater than the number of guardians")
+	}
+	if _, err := b.BuildSetupTx(context.Background(), testutil.Address(), nil, nil); err == nil {
+		t.Errorf("BuildSetupTx() should fail without the config")
+	}
+}
+
+func TestConfigWithGuardianLocks(t *testing.T) {
+	sighashLock := &types.Script{
+		CodeHash: systemscript.GetCodeHash(types.NetworkTest, systemscript.Secp256k1Blake160SighashAll),
+		HashType: types.HashTypeType,
+		Args:     make([]byte, 20),
+	}
+	joyidLock := guardianLock(guardianKeys[0])
+	config := &Config{Guardians: []*types.Script{joyidLock, sighashLock}, Threshold: 1}
+	if err := config.validate(types.NetworkTest); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+
+	// the locks with the same code hash and another hash type aren't the guardian locks
+	for _, lock := range []*types.Script{
+		{CodeHash: sighashLock.CodeHash, HashType: types.HashTypeData, Args: sighashLock.Args},
+		{CodeHash: joyidLock.CodeHash, HashType: types.HashTypeData1, Args: joyidLock.Args},
+		nil,
+	} {
+		config := &Config{Guardians: []*types.Script{joyidLock, lock}, Threshold: 1}
+		if err := config.validate(types.NetworkTest); err == nil {
+			t.Errorf("validate() should fail with the guardian lock %+v", lock)
+		}
+	}
+}
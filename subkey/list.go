@@ -0,0 +1,317 @@
+package subkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/cota"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/keccak"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/indexer"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/rpc"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const (
+	// ext_data(4) + alg_index(2) + pubkey_hash(20) of the molecule struct SubKey
+	subkeyLen    = 26
+	historyLimit = 100
+)
+
+// SubkeyInfo is the subkey registered in the CoTA cell of the JoyID account
+type SubkeyInfo struct {
+	Subkey
+	// BlockNumber is the block of the transaction which added or updated the subkey, and it is zero with
+	// ExtData for the subkeys of the aggregator which doesn't serve them
+	BlockNumber uint64
+	// Pubkey, CredentialId and FrontEnd are the device info of the aggregator, and they are empty if the
+	// aggregator is not reachable or doesn't list the subkey
+	Pubkey       []byte
+	CredentialId []byte
+	FrontEnd     string
+}
+
+// ListSubkeys returns the subkeys of the aggregator JoyID info with the device info. The subkeys are decoded
+// from the extension entries of the CoTA cell transactions as the fallback only if the aggregator doesn't
+// serve JoyID info or is unavailable, and they are ordered by ext_data without the device info.
+func (m *Manager) ListSubkeys(ctx context.Context, addr *address.Address) ([]SubkeyInfo, error) {
+	info, err := m.Builder.Aggregator.GetJoyIDInfoContext(ctx, addr.Script)
+	if err != nil {
+		if !isFallback(ctx, err) {
+			return nil, err
+		}
+		return m.historySubkeys(ctx, addr)
+	}
+	return aggregatorSubkeys(info)
+}
+
+// ListSlots returns every ext_data slot of the JoyID address ordered by ext_data, which is decoded from the
+// extension entries of the CoTA cell transactions, with the device info of the aggregator. The slot whose
+// subkey isn't listed by the aggregator is returned without the device info, and the device info is skipped
+// if the aggregator doesn't serve JoyID info or is unavailable.
+func (m *Manager) ListSlots(ctx context.Context, addr *address.Address) ([]SubkeyInfo, error) {
+	slots, err := m.historySubkeys(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	info, err := m.Builder.Aggregator.GetJoyIDInfoContext(ctx, addr.Script)
+	if err != nil {
+		if !isFallback(ctx, err) {
+			return nil, err
+		}
+		return slots, nil
+	}
+	devices, err := aggregatorSubkeys(info)
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		for _, device := range devices {
+			if device.Alg == slots[i].Alg && bytes.Equal(device.PubkeyHash, slots[i].PubkeyHash) {
+				slots[i].Pubkey = device.Pubkey
+				slots[i].CredentialId = device.CredentialId
+				slots[i].FrontEnd = device.FrontEnd
+				break
+			}
+		}
+	}
+	return slots, nil
+}
+
+// NextExtData returns the ext_data following the largest one which has ever been used by the subkeys of
+// the JoyID address, so the slot of a subkey which the aggregator doesn't list is never reused
+func (m *Manager) NextExtData(ctx context.Context, addr *address.Address) (uint32, error) {
+	// the history keeps every slot of the extension entries, including the ones the aggregator doesn't list
+	slots, err := m.historySubkeys(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	return nextExtData(slots), nil
+}
+
+// aggregatorSubkeys returns the subkeys of the aggregator JoyID info with the pubkey hashes of the pubkeys
+func aggregatorSubkeys(info *aggregator.JoyIDInfoResult) ([]SubkeyInfo, error) {
+	subkeys := make([]SubkeyInfo, 0, len(info.Subkeys))
+	for _, device := range info.Subkeys {
+		algIndex, err := parseAlg(device.Alg)
+		if err != nil {
+			return nil, err
+		}
+		subkeys = append(subkeys, SubkeyInfo{
+			Subkey:       Subkey{Alg: algIndex, PubkeyHash: PubkeyHash(device.PubKey, algIndex)},
+			Pubkey:       device.PubKey,
+			CredentialId: device.CredentialId,
+			FrontEnd:     device.FrontEnd,
+		})
+	}
+	return subkeys, nil
+}
+
+// nextExtData returns the ext_data following the largest one of the slots, and it is 1 without slots
+func nextExtData(slots []SubkeyInfo) uint32 {
+	next := uint32(1)
+	for _, slot := range slots {
+		if slot.ExtData >= next {
+			next = slot.ExtData + 1
+		}
+	}
+	return next
+}
+
+// isFallback reports whether the subkeys are decoded from the history for the aggregator error, which is
+// the method not found or the unavailable aggregator, and the error of the done context is returned as is
+func isFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rpcErr *aggregator.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == aggregator.ErrCodeMethodNotFound
+	}
+	return errors.Is(err, aggregator.ErrUnavailable)
+}
+
+// PubkeyHash returns the pubkey hash of the 64-byte uncompressed pubkey, which is blake160 for secp256r1
+// and keccak160 for secp256k1
+func PubkeyHash(pubkey []byte, algIndex alg.AlgIndex) []byte {
+	if len(pubkey) == 65 && pubkey[0] == 0x04 {
+		pubkey = pubkey[1:]
+	}
+	if algIndex == alg.Secp256k1 {
+		return keccak.Keccak160(pubkey)
+	}
+	return blake2b.Blake160(pubkey)
+}
+
+// historySubkeys replays the extension entries of the CoTA cell transactions of the JoyID address, and the
+// transactions of each page of the indexer are fetched by one batch request
+func (m *Manager) historySubkeys(ctx context.Context, addr *address.Address) ([]SubkeyInfo, error) {
+	nodeUrl := m.NodeUrl
+	if nodeUrl == "" {
+		nodeUrl = m.Builder.IndexerUrl
+	}
+	client, err := rpc.Dial(nodeUrl)
+	if err != nil {
+		return nil, err
+	}
+	searchKey := &indexer.SearchKey{
+		Script:     utils.CotaTypeScript(addr.Network, addr.Script.Hash()),
+		ScriptType: types.ScriptTypeType,
+	}
+	slots := make(map[uint32]SubkeyInfo)
+	afterCursor := ""
+	for {
+		resp, err := client.GetTransactions(ctx, searchKey, indexer.SearchOrderAsc, historyLimit, afterCursor)
+		if err != nil {
+			return nil, err
+		}
+		var inputs []*indexer.TxWithCell
+		var batch []types.BatchTransactionItem
+		for _, txWithCell := range resp.Objects {
+			if txWithCell.IoType == indexer.IOTypeIn {
+				inputs = append(inputs, txWithCell)
+				batch = append(batch, types.BatchTransactionItem{Hash: txWithCell.TxHash})
+			}
+		}
+		if len(batch) > 0 {
+			if err := client.BatchTransactions(ctx, batch); err != nil {
+				return nil, err
+			}
+		}
+		for i, item := range batch {
+			if item.Error != nil {
+				return nil, fmt.Errorf("failed to get transaction %s, %w", item.Hash, item.Error)
+			}
+			if err := replayExtension(inputs[i], item.Result, slots); err != nil {
+				return nil, err
+			}
+		}
+		if len(resp.Objects) < historyLimit || resp.LastCursor == afterCursor {
+			break
+		}
+		afterCursor = resp.LastCursor
+	}
+
+	subkeys := make([]SubkeyInfo, 0, len(slots))
+	for _, subkey := range slots {
+		subkeys = append(subkeys, subkey)
+	}
+	sort.Slice(subkeys, func(i, j int) bool {
+		return subkeys[i].ExtData < subkeys[j].ExtData
+	})
+	return subkeys, nil
+}
+
+// replayExtension applies the extension entries in WitnessArgs.InputType of the consumed CoTA cell
+func replayExtension(txWithCell *indexer.TxWithCell, tx *types.TransactionWithStatus, slots map[uint32]SubkeyInfo) error {
+	if tx == nil || tx.Transaction == nil || int(txWithCell.IoIndex) >= len(tx.Transaction.Witnesses) {
+		return fmt.Errorf("witness of the CoTA cell in transaction %s doesn't exist", txWithCell.TxHash)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.Transaction.Witnesses[txWithCell.IoIndex])
+	if err != nil || len(witnessArgs.InputType) == 0 {
+		// the CoTA cell may be updated without WitnessArgs.InputType, e.g. the fee is paid by the CoTA cell
+		return nil
+	}
+	if witnessArgs.InputType[0] != cota.ActionExtension {
+		return nil
+	}
+	subkeys, err := DecodeExtensionSubkeys(witnessArgs.InputType[1:])
+	if err != nil {
+		return fmt.Errorf("invalid extension entries of transaction %s, %w", txWithCell.TxHash, err)
+	}
+	for _, subkey := range subkeys {
+		slots[subkey.ExtData] = SubkeyInfo{Subkey: subkey, BlockNumber: txWithCell.BlockNumber}
+	}
+	return nil
+}
+
+// DecodeExtensionSubkeys decodes the subkeys of the extension SMT entry, which is the molecule table
+// ExtensionEntries { leaves, sub_type, raw_data }, and raw_data is the fixvec of the struct
+// SubKey { ext_data: Uint32, alg_index: Uint16, pubkey_hash: Byte20 } in big-endian
+func DecodeExtensionSubkeys(entry []byte) ([]Subkey, error) {
+	fields, err := moleculeTableFields(entry)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < 3 {
+		return nil, errors.New("extension entries must have leaves, sub_type and raw_data")
+	}
+	rawData, err := moleculeBytes(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	if len(rawData) < 4 {
+		return nil, errors.New("invalid subkey vec")
+	}
+	count := binary.LittleEndian.Uint32(rawData)
+	if uint64(len(rawData)) != 4+uint64(count)*subkeyLen {
+		return nil, errors.New("invalid subkey vec length")
+	}
+	subkeys := make([]Subkey, 0, count)
+	for i := 0; i < int(count); i++ {
+		item := rawData[4+i*subkeyLen : 4+(i+1)*subkeyLen]
+		subkeys = append(subkeys, Subkey{
+			ExtData:    binary.BigEndian.Uint32(item[:4]),
+			Alg:        alg.AlgIndex(binary.BigEndian.Uint16(item[4:6])),
+			PubkeyHash: append([]byte{}, item[6:]...),
+		})
+	}
+	return subkeys, nil
+}
+
+// moleculeTableFields splits the molecule table into the fields with the header of total size and offsets
+func moleculeTableFields(data []byte) ([][]byte, error) {
+	if len(data) < 4 || binary.LittleEndian.Uint32(data) != uint32(len(data)) {
+		return nil, errors.New("invalid molecule table size")
+	}
+	if len(data) == 4 {
+		return nil, nil
+	}
+	if len(data) < 8 {
+		return nil, errors.New("invalid molecule table header")
+	}
+	headerLen := binary.LittleEndian.Uint32(data[4:])
+	if headerLen%4 != 0 || headerLen < 8 || int(headerLen) > len(data) {
+		return nil, errors.New("invalid molecule table header")
+	}
+	offsets := make([]uint32, 0, headerLen/4)
+	for i := uint32(4); i < headerLen; i += 4 {
+		offsets = append(offsets, binary.LittleEndian.Uint32(data[i:]))
+	}
+	offsets = append(offsets, uint32(len(data)))
+	fields := make([][]byte, 0, len(offsets)-1)
+	for i := 0; i < len(offsets)-1; i++ {
+		if offsets[i] < headerLen || offsets[i] > offsets[i+1] {
+			return nil, errors.New("invalid molecule table offsets")
+		}
+		fields = append(fields, data[offsets[i]:offsets[i+1]])
+	}
+	return fields, nil
+}
+
+// moleculeBytes returns the content of the molecule Bytes which is the fixvec of byte
+func moleculeBytes(data []byte) ([]byte, error) {
+	if len(data) < 4 || uint64(binary.LittleEndian.Uint32(data))+4 != uint64(len(data)) {
+		return nil, errors.New("invalid molecule bytes")
+	}
+	return data[4:], nil
+}
+
+// parseAlg parses the alg of the aggregator JoyID info, e.g. "01" or "0x01"
+func parseAlg(value string) (alg.AlgIndex, error) {
+	algIndex, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid alg %s of the subkey", value)
+	}
+	return alg.AlgIndex(algIndex), nil
+}
@@ -0,0 +1,295 @@
+package subkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/cota"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// moleculeTable serializes the fields into the molecule table
+func moleculeTable(fields ...[]byte) []byte {
+	headerLen := 4 + 4*len(fields)
+	data := binary.LittleEndian.AppendUint32(nil, 0)
+	offset := headerLen
+	for _, field := range fields {
+		data = binary.LittleEndian.AppendUint32(data, uint32(offset))
+		offset += len(field)
+	}
+	for _, field := range fields {
+		data = append(data, field...)
+	}
+	binary.LittleEndian.PutUint32(data, uint32(len(data)))
+	return data
+}
+
+func moleculeBytesOf(data []byte) []byte {
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data...)
+}
+
+// extensionInputType returns WitnessArgs.InputType of the CoTA cell with the extension entries of the subkeys
+func extensionInputType(subkeys ...Subkey) []byte {
+	rawData := binary.LittleEndian.AppendUint32(nil, uint32(len(subkeys)))
+	for _, subkey := range subkeys {
+		rawData = binary.BigEndian.AppendUint32(rawData, subkey.ExtData)
+		rawData = binary.BigEndian.AppendUint16(rawData, uint16(subkey.Alg))
+		rawData = append(rawData, subkey.PubkeyHash...)
+	}
+	entry := moleculeTable(moleculeTable(), moleculeBytesOf([]byte("subkey")), moleculeBytesOf(rawData))
+	return append([]byte{cota.ActionExtension}, entry...)
+}
+
+// historyHandlers serves the CoTA cell transactions of the witnesses, and the block number of each one
+// is 10 times its index plus 10
+func historyHandlers(witnesses ...[]byte) map[string]func(json.RawMessage) string {
+	return map[string]func(json.RawMessage) string{
+		"get_transactions": func(params json.RawMessage) string {
+			objects := []map[string]interface{}{
+				// the CoTA cell output of the registry transaction
+				{"block_number": "0x1", "io_index": "0x1", "io_type": "output", "tx_hash": types.Hash{0xff}, "tx_index": "0x1"},
+			}
+			for i := range witnesses {
+				objects = append(objects, map[string]interface{}{
+					"block_number": fmt.Sprintf("0x%x", 10*i+10),
+					"io_index":     "0x0",
+					"io_type":      "input",
+					"tx_hash":      types.Hash{byte(i)},
+					"tx_index":     "0x1",
+				})
+			}
+			data, _ := json.Marshal(map[string]interface{}{"last_cursor": "0x", "objects": objects})
+			return string(data)
+		},
+		"get_transaction": func(params json.RawMessage) string {
+			var hashes []types.Hash
+			if err := json.Unmarshal(params, &hashes); err != nil || len(hashes) == 0 {
+				return "null"
+			}
+			tx := &types.Transaction{
+				CellDeps:    []*types.CellDep{},
+				HeaderDeps:  []types.Hash{},
+				Inputs:      []*types.CellInput{{PreviousOutput: &types.OutPoint{TxHash: types.Hash{0x01}}}},
+				Outputs:     []*types.CellOutput{},
+				OutputsData: [][]byte{},
+				Witnesses:   [][]byte{witnesses[hashes[0][0]]},
+			}
+			data, _ := json.Marshal(map[string]interface{}{
+				"transaction": tx,
+				"tx_status":   map[string]interface{}{"status": "committed", "block_hash": types.Hash{}},
+			})
+			return string(data)
+		},
+	}
+}
+
+func TestListSubkeys(t *testing.T) {
+	key, err := secp256k1.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pubkey := key.Pubkey()
+	hashA, hashB := bytes.Repeat([]byte{0x0a}, 20), bytes.Repeat([]byte{0x0b}, 20)
+	handlers := historyHandlers(
+		(&types.WitnessArgs{InputType: extensionInputType(
+			Subkey{ExtData: 1, Alg: alg.Secp256r1, PubkeyHash: hashA},
+			Subkey{ExtData: 2, Alg: alg.Secp256r1, PubkeyHash: hashB},
+		)}).Serialize(),
+		(&types.WitnessArgs{InputType: extensionInputType(
+			Subkey{ExtData: 2, Alg: alg.Secp256k1, PubkeyHash: key.PubkeyHash()},
+		)}).Serialize(),
+		// the CoTA cell transaction which isn't the extension
+		(&types.WitnessArgs{InputType: []byte{0x06, 0x00}}).Serialize(),
+	)
+	node, server := testutil.NewNode(t)
+	node.Handlers = handlers
+	defer server.Close()
+	manager := NewManager(types.NetworkTest, server.URL, server.URL)
+
+	// the aggregator doesn't serve get_joyid_info
+	subkeys, err := manager.ListSubkeys(context.Background(), testutil.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subkeys) != 2 || subkeys[0].ExtData != 1 || subkeys[0].BlockNumber != 10 || !bytes.Equal(subkeys[0].PubkeyHash, hashA) {
+		t.Errorf("ListSubkeys() = %+v", subkeys)
+	}
+	if subkeys[1].ExtData != 2 || subkeys[1].Alg != alg.Secp256k1 || subkeys[1].BlockNumber != 20 || !bytes.Equal(subkeys[1].PubkeyHash, key.PubkeyHash()) {
+		t.Errorf("ListSubkeys() = %+v", subkeys)
+	}
+
+	if node.Batches != 1 {
+		t.Errorf("ListSubkeys() batch requests = %d, want 1", node.Batches)
+	}
+
+	handlers["get_joyid_info"] = func(json.RawMessage) string {
+		return fmt.Sprintf(`{"name":"joy","pub_key":"0x01","alg":"01","block_number":40,
+			"sub_keys":[{"pub_key":"%s","credential_id":"0x03","alg":"02","front_end":"app.joy.id"}]}`, utils.BytesTo0xHex(pubkey))
+	}
+	delete(node.Params, "get_transactions")
+	subkeys, err = manager.ListSubkeys(context.Background(), testutil.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subkeys) != 1 || subkeys[0].ExtData != 0 || subkeys[0].Alg != alg.Secp256k1 || subkeys[0].FrontEnd != "app.joy.id" ||
+		!bytes.Equal(subkeys[0].Pubkey, pubkey) || !bytes.Equal(subkeys[0].PubkeyHash, key.PubkeyHash()) {
+		t.Errorf("ListSubkeys() = %+v", subkeys)
+	}
+	if _, ok := node.Params["get_transactions"]; ok {
+		t.Errorf("ListSubkeys() should not decode the history if the aggregator serves JoyID info")
+	}
+
+	// the slot 1 isn't listed by the aggregator, and it is kept without the device info
+	slots, err := manager.ListSlots(context.Background(), testutil.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 2 || slots[0].ExtData != 1 || slots[0].FrontEnd != "" || !bytes.Equal(slots[0].PubkeyHash, hashA) {
+		t.Errorf("ListSlots() = %+v", slots)
+	}
+	if slots[1].ExtData != 2 || slots[1].BlockNumber != 20 || slots[1].FrontEnd != "app.joy.id" || !bytes.Equal(slots[1].Pubkey, pubkey) {
+		t.Errorf("ListSlots() = %+v", slots)
+	}
+
+	next, err := manager.NextExtData(context.Background(), testutil.Address())
+	if err != nil || next != 3 {
+		t.Errorf("NextExtData() = %d, %v, want 3", next, err)
+	}
+}
+
+func TestDecodeExtensionSubkeys(t *testing.T) {
+	// the extension entries are written out by hand from the molecule schema instead of extensionInputType
+	entry, err := utils.HexToBytes("0x" +
+		// total size 64 and the offsets of leaves, sub_type and raw_data
+		"40000000" + "10000000" + "14000000" + "1e000000" +
+		// empty leaves
+		"04000000" +
+		// sub_type "subkey"
+		"06000000" + "7375626b6579" +
+		// raw_data of 30 bytes with one SubKey { ext_data: 0x01020304, alg_index: 2, pubkey_hash }
+		"1e000000" + "01000000" + "01020304" + "0002" + "1111111111111111111111111111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkeyHash := bytes.Repeat([]byte{0x11}, 20)
+	subkeys, err := DecodeExtensionSubkeys(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subkeys) != 1 || subkeys[0].ExtData != 0x01020304 || subkeys[0].Alg != alg.Secp256k1 || !bytes.Equal(subkeys[0].PubkeyHash, pubkeyHash) {
+		t.Errorf("DecodeExtensionSubkeys() = %+v", subkeys)
+	}
+	if got := extensionInputType(subkeys...)[1:]; !bytes.Equal(got, entry) {
+		t.Errorf("extensionInputType() = %x, want %x", got, entry)
+	}
+	for _, invalid := range [][]byte{nil, entry[:len(entry)-1], moleculeTable(moleculeTable())} {
+		if _, err := DecodeExtensionSubkeys(invalid); err == nil {
+			t.Errorf("DecodeExtensionSubkeys(%x) should fail", invalid)
+		}
+	}
+}
+
+func TestNextExtData(t *testing.T) {
+	if got := nextExtData(nil); got != 1 {
+		t.Errorf("nextExtData() = %d, want 1", got)
+	}
+	if got := nextExtData([]SubkeyInfo{{Subkey: Subkey{ExtData: 5}}, {Subkey: Subkey{ExtData: 2}}}); got != 6 {
+		t.Errorf("nextExtData() = %d, want 6", got)
+	}
+}
+
+func TestNextExtDataWithUnlistedTopSlot(t *testing.T) {
+	var slots []Subkey
+	var devices []string
+	for extData := uint32(1); extData <= 3; extData++ {
+		key, err := secp256k1.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, pubkey := key.Pubkey()
+		slots = append(slots, Subkey{ExtData: extData, Alg: alg.Secp256k1, PubkeyHash: key.PubkeyHash()})
+		if extData < 3 {
+			devices = append(devices, fmt.Sprintf(`{"pub_key":"%s","credential_id":"0x03","alg":"02","front_end":"app.joy.id"}`, utils.BytesTo0xHex(pubkey)))
+		}
+	}
+	handlers := historyHandlers((&types.WitnessArgs{InputType: extensionInputType(slots...)}).Serialize())
+	// the aggregator only lists the subkeys of the slots 1 and 2
+	handlers["get_joyid_info"] = func(json.RawMessage) string {
+		return `{"name":"joy","pub_key":"0x01","alg":"01","block_number":40,"sub_keys":[` + strings.Join(devices, ",") + `]}`
+	}
+	node, server := testutil.NewNode(t)
+	node.Handlers = handlers
+	defer server.Close()
+	manager := NewManager(types.NetworkTest, server.URL, server.URL)
+
+	subkeys, err := manager.ListSlots(context.Background(), testutil.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subkeys) != 3 || subkeys[0].FrontEnd == "" || subkeys[1].FrontEnd == "" || subkeys[2].ExtData != 3 || subkeys[2].FrontEnd != "" {
+		t.Errorf("ListSlots() = %+v, want the slot 3 without the device info", subkeys)
+	}
+	next, err := manager.NextExtData(context.Background(), testutil.Address())
+	if err != nil || next != 4 {
+		t.Errorf("NextExtData() = %d, %v, want 4", next, err)
+	}
+}
+
+func TestListSubkeysWithAggregatorError(t *testing.T) {
+	handlers := historyHandlers((&types.WitnessArgs{InputType: extensionInputType(
+		Subkey{ExtData: 1, Alg: alg.Secp256k1, PubkeyHash: bytes.Repeat([]byte{0x0a}, 20)},
+	)}).Serialize())
+	node, server := testutil.NewNode(t)
+	node.Handlers = handlers
+	defer server.Close()
+
+	// the unavailable aggregator falls back to the history
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	manager := NewManager(types.NetworkTest, unavailable.URL, server.URL)
+	subkeys, err := manager.ListSubkeys(context.Background(), testutil.Address())
+	if err != nil || len(subkeys) != 1 || subkeys[0].ExtData != 1 {
+		t.Errorf("ListSubkeys() = %+v, %v, want the subkey of the history", subkeys, err)
+	}
+
+	// the other aggregator errors are returned
+	rpcError := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"internal error"}}`, req.Id)
+	}))
+	defer rpcError.Close()
+	manager = NewManager(types.NetworkTest, rpcError.URL, server.URL)
+	var rpcErr *aggregator.RPCError
+	if _, err := manager.ListSubkeys(context.Background(), testutil.Address()); !errors.As(err, &rpcErr) {
+		t.Errorf("ListSubkeys() error = %v, want *aggregator.RPCError", err)
+	}
+
+	// the canceled context isn't the unavailable aggregator
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	manager = NewManager(types.NetworkTest, unavailable.URL, server.URL)
+	if _, err := manager.ListSubkeys(ctx, testutil.Address()); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListSubkeys() error = %v, want %v", err, context.Canceled)
+	}
+}
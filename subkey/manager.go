@@ -0,0 +1,84 @@
+package subkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nervina-labs/joyid-sdk-go/aggregator"
+	"github.com/nervina-labs/joyid-sdk-go/cota"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/handler"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const pubkeyHashLen = 20
+
+// Subkey is the subkey of the JoyID account in the ext_data slot
+type Subkey struct {
+	// ExtData must be unique for each subkey of the JoyID account
+	ExtData    uint32
+	Alg        alg.AlgIndex
+	PubkeyHash []byte
+}
+
+// Manager builds the CoTA cell transactions which add and update the subkeys of the JoyID account,
+// and the fee is paid by the CoTA cell
+type Manager struct {
+	Builder *cota.Builder
+	// NodeUrl is the CKB node which serves get_transaction to list the subkeys, and the indexer url of the
+	// builder is used if it is empty
+	NodeUrl string
+}
+
+func NewManager(network types.Network, aggregatorUrl, indexerUrl string) *Manager {
+	return &Manager{Builder: cota.NewBuilder(network, aggregatorUrl, indexerUrl)}
+}
+
+// BuildAddTx builds the transaction which adds the subkeys into the empty ext_data slots
+func (m *Manager) BuildAddTx(ctx context.Context, addr *address.Address, subkeys []Subkey, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	return m.build(ctx, addr, aggregator.ExtActionAddSubkey, subkeys, unlockCtx)
+}
+
+// BuildUpdateTx builds the transaction which replaces the subkeys in the existing ext_data slots, e.g. the
+// subkey of a lost device is replaced with the one of a new device. The CoTA extension has no action to
+// remove a subkey, so a subkey is revoked by overwriting its slot with the subkey of another key.
+func (m *Manager) BuildUpdateTx(ctx context.Context, addr *address.Address, subkeys []Subkey, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	return m.build(ctx, addr, aggregator.ExtActionUpdateSubkey, subkeys, unlockCtx)
+}
+
+func (m *Manager) build(ctx context.Context, addr *address.Address, extAction byte, subkeys []Subkey, unlockCtx *handler.JoyIDUnlockContext) (*transaction.TransactionWithScriptGroups, error) {
+	extSubkeys, err := extensionSubkeys(subkeys)
+	if err != nil {
+		return nil, err
+	}
+	return m.Builder.BuildExtensionTx(ctx, addr, extAction, extSubkeys, unlockCtx)
+}
+
+func extensionSubkeys(subkeys []Subkey) ([]aggregator.ExtensionSubkey, error) {
+	if len(subkeys) == 0 {
+		return nil, errors.New("subkeys cannot be empty")
+	}
+	extDataSet := make(map[uint32]bool, len(subkeys))
+	extSubkeys := make([]aggregator.ExtensionSubkey, 0, len(subkeys))
+	for _, subkey := range subkeys {
+		if subkey.Alg != alg.Secp256r1 && subkey.Alg != alg.Secp256k1 {
+			return nil, fmt.Errorf("unknown alg index %d of the subkey", subkey.Alg)
+		}
+		if len(subkey.PubkeyHash) != pubkeyHashLen {
+			return nil, fmt.Errorf("invalid pubkey hash length %d of the subkey", len(subkey.PubkeyHash))
+		}
+		if extDataSet[subkey.ExtData] {
+			return nil, fmt.Errorf("duplicate ext_data %d of the subkeys", subkey.ExtData)
+		}
+		extDataSet[subkey.ExtData] = true
+		extSubkeys = append(extSubkeys, aggregator.ExtensionSubkey{
+			ExtData:    subkey.ExtData,
+			AlgIndex:   subkey.Alg,
+			PubkeyHash: subkey.PubkeyHash,
+		})
+	}
+	return extSubkeys, nil
+}
@@ -0,0 +1,72 @@
+package subkey
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/internal/testutil"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+func TestBuildUpdateTx(t *testing.T) {
+	node, server := testutil.NewNode(t)
+	node.Results["generate_extension_subkey_smt"] = `{"smt_root_hash":"0x` + utils.BytesToHex(make([]byte, 32)) + `","extension_smt_entry":"0xaabb","block_number":1}`
+	defer server.Close()
+
+	manager := NewManager(types.NetworkTest, server.URL, server.URL)
+	pubkeyHash := make([]byte, 20)
+	pubkeyHash[0] = 0x01
+	tx, err := manager.BuildUpdateTx(context.Background(), testutil.Address(), []Subkey{
+		{ExtData: 1, Alg: alg.Secp256r1, PubkeyHash: pubkeyHash},
+		{ExtData: 2, Alg: alg.Secp256k1, PubkeyHash: pubkeyHash},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.TxView.Witnesses[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := utils.BytesToHex(witnessArgs.InputType); got != "f0aabb" {
+		t.Errorf("WitnessArgs.InputType = %s, want f0aabb", got)
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(node.Params["generate_extension_subkey_smt"], &params); err != nil {
+		t.Fatal(err)
+	}
+	if params["ext_action"] != "0xf1" {
+		t.Errorf("ext_action = %v, want 0xf1", params["ext_action"])
+	}
+	subkeys := params["subkeys"].([]interface{})
+	subkey := subkeys[1].(map[string]interface{})
+	if len(subkeys) != 2 || subkey["ext_data"] != float64(2) || subkey["alg_index"] != float64(2) || subkey["pubkey_hash"] != utils.BytesTo0xHex(pubkeyHash) {
+		t.Errorf("subkeys = %v", subkeys)
+	}
+}
+
+func TestBuildAddTxWithInvalidSubkeys(t *testing.T) {
+	manager := NewManager(types.NetworkTest, "http://localhost", "http://localhost")
+	pubkeyHash := make([]byte, 20)
+	tests := []struct {
+		name    string
+		subkeys []Subkey
+	}{
+		{"empty", nil},
+		{"unknown alg", []Subkey{{ExtData: 1, Alg: 3, PubkeyHash: pubkeyHash}}},
+		{"short pubkey hash", []Subkey{{ExtData: 1, Alg: alg.Secp256r1, PubkeyHash: pubkeyHash[:19]}}},
+		{"duplicate ext_data", []Subkey{
+			{ExtData: 1, Alg: alg.Secp256r1, PubkeyHash: pubkeyHash},
+			{ExtData: 1, Alg: alg.Secp256k1, PubkeyHash: pubkeyHash},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.BuildAddTx(context.Background(), testutil.Address(), tt.subkeys, nil); err == nil {
+				t.Errorf("BuildAddTx() should fail")
+			}
+		})
+	}
+}
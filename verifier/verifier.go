@@ -0,0 +1,163 @@
+package verifier
+
+import (
+	"bytes"
+	"fmt"
+
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/keccak"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256k1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+	"github.com/nervina-labs/joyid-sdk-go/deployment"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+	"github.com/nervina-labs/joyid-sdk-go/webauthn"
+	"github.com/nervina-labs/joyid-sdk-go/witness"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/crypto/blake2b"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+// ErrorCode tells why the JoyID witness fails to be verified
+type ErrorCode int
+
+const (
+	ErrInvalidScriptGroup ErrorCode = iota + 1
+	ErrInvalidWitness
+	ErrUnknownUnlockMode
+	ErrPubkeyHashMismatch
+	ErrChallengeMismatch
+	ErrInvalidSignature
+)
+
+func (code ErrorCode) String() string {
+	switch code {
+	case ErrInvalidScriptGroup:
+		return "invalid script group"
+	case ErrInvalidWitness:
+		return "invalid witness"
+	case ErrUnknownUnlockMode:
+		return "unknown unlock mode"
+	case ErrPubkeyHashMismatch:
+		return "pubkey hash mismatch"
+	case ErrChallengeMismatch:
+		return "challenge mismatch"
+	case ErrInvalidSignature:
+		return "invalid signature"
+	default:
+		return fmt.Sprintf("unknown error code %d", int(code))
+	}
+}
+
+// VerifyError is returned when the JoyID witness of the script group fails to be verified
+type VerifyError struct {
+	Code ErrorCode
+	// InputIndex is the index of the first input of the script group
+	InputIndex uint32
+	Message    string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("JoyID witness of input %d: %s, %s", e.InputIndex, e.Code, e.Message)
+}
+
+// VerifyTransaction verifies the witnesses of all JoyID lock script groups of the transaction
+func VerifyTransaction(tx *transaction.TransactionWithScriptGroups) error {
+	for _, group := range tx.ScriptGroups {
+		if group.GroupType != types.ScriptTypeLock || !deployment.IsJoyIDLock(group.Script) {
+			continue
+		}
+		if err := VerifyScriptGroup(tx.TxView, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyScriptGroup checks the signature of the witness of the JoyID lock script group offline, and it doesn't
+// replace the on-chain lock, e.g. the pubkey hash of subkey unlock isn't checked because it is committed in
+// the CoTA SMT
+func VerifyScriptGroup(tx *types.Transaction, group *transaction.ScriptGroup) error {
+	if group == nil || len(group.InputIndices) == 0 || int(group.InputIndices[0]) >= len(tx.Witnesses) {
+		return &VerifyError{Code: ErrInvalidScriptGroup, Message: "input indices are out of range"}
+	}
+	index := group.InputIndices[0]
+	newError := func(code ErrorCode, format string, args ...interface{}) error {
+		return &VerifyError{Code: code, InputIndex: index, Message: fmt.Sprintf(format, args...)}
+	}
+	if group.Script == nil || len(group.Script.Args) != 22 {
+		return newError(ErrInvalidScriptGroup, "JoyID lock args must be 22 bytes")
+	}
+	witnessArgs, err := types.DeserializeWitnessArgs(tx.Witnesses[index])
+	if err != nil {
+		return newError(ErrInvalidWitness, "witness must be WitnessArgs")
+	}
+	if len(witnessArgs.Lock) > 0 && witnessArgs.Lock[0] != byte(witness.NativeUnlock) && witnessArgs.Lock[0] != byte(witness.SubkeyUnlock) {
+		return newError(ErrUnknownUnlockMode, "unlock mode %d", witnessArgs.Lock[0])
+	}
+	lock, err := witness.Deserialize(witnessArgs.Lock)
+	if err != nil {
+		return newError(ErrInvalidWitness, err.Error())
+	}
+
+	var pubkeyHash []byte
+	switch l := lock.(type) {
+	case *witness.Secp256k1Lock:
+		pubkeyHash, err = verifySecp256k1(tx, group, l)
+	case *witness.Secp256r1Lock:
+		pubkeyHash, err = verifySecp256r1(tx, group, l)
+	}
+	if err != nil {
+		if e, ok := err.(*VerifyError); ok {
+			e.InputIndex = index
+		}
+		return err
+	}
+
+	// the alg of subkey isn't in the lock args and the subkey is committed in the CoTA SMT
+	if lock.UnlockMode() == witness.NativeUnlock {
+		args := joyidaddress.LockArgs(pubkeyHash, lock.Alg())
+		if !bytes.Equal(args, group.Script.Args) {
+			return newError(ErrPubkeyHashMismatch, "pubkey hash %x doesn't match lock args %x", pubkeyHash, group.Script.Args)
+		}
+	}
+	return nil
+}
+
+func verifySecp256k1(tx *types.Transaction, group *transaction.ScriptGroup, lock *witness.Secp256k1Lock) ([]byte, error) {
+	digest, err := signer.Secp256k1SigningDigest(tx, group)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrInvalidScriptGroup, Message: err.Error()}
+	}
+	pubkey, err := secp256k1.RecoverPubkey(digest, lock.Signature)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrInvalidSignature, Message: err.Error()}
+	}
+	if recovered := keccak.Keccak160(pubkey[1:]); !bytes.Equal(recovered, lock.PubkeyHash) {
+		return nil, &VerifyError{Code: ErrInvalidSignature, Message: fmt.Sprintf("recovered pubkey hash %x doesn't match %x", recovered, lock.PubkeyHash)}
+	}
+	return lock.PubkeyHash, nil
+}
+
+func verifySecp256r1(tx *types.Transaction, group *transaction.ScriptGroup, lock *witness.Secp256r1Lock) ([]byte, error) {
+	challenge, err := signer.GenerateWebAuthnChallenge(tx, group)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrInvalidScriptGroup, Message: err.Error()}
+	}
+	expectedChallenge, _ := utils.HexToBytes(challenge)
+	clientData, err := webauthn.ParseClientData(lock.ClientData)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrInvalidWitness, Message: err.Error()}
+	}
+	if clientData.Challenge != string(expectedChallenge) {
+		return nil, &VerifyError{Code: ErrChallengeMismatch, Message: fmt.Sprintf("challenge %s of clientData, want %s", clientData.Challenge, expectedChallenge)}
+	}
+
+	signData := append([]byte{}, lock.AuthData...)
+	signData = append(signData, sha256.Sha256(lock.ClientData)...)
+	if !secp256r1.Verify(lock.Pubkey, sha256.Sha256(signData), lock.Signature) {
+		return nil, &VerifyError{Code: ErrInvalidSignature, Message: "secp256r1 signature verification failed"}
+	}
+	return blake2b.Blake160(lock.Pubkey), nil
+}
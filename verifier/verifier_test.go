@@ -0,0 +1,106 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/signer"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/transaction"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const (
+	k1PrivKey = "0xccb083b37aa346c5ce2e1f99a687a153baa04052f26db6ab3c26d6a4cc15c5f1"
+	r1PrivKey = "0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761"
+)
+
+func testWebAuthnMsg(challenge string) (*signer.WebAuthnMsg, error) {
+	authData := "49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630162f9fb77"
+	clientData := fmt.Sprintf("7b2274797065223a22776562617574686e2e676574222c226368616c6c656e6765223a22%s222c226f726967696e223a22687474703a2f2f6c6f63616c686f73743a38303030222c2263726f73734f726967696e223a66616c73657d", challenge)
+	return &signer.WebAuthnMsg{AuthData: authData, ClientData: clientData}, nil
+}
+
+func signedTransaction(t *testing.T, key signer.AlgPrivKey, lockKey signer.AlgPrivKey, mode signer.UnlockMode) (*types.Transaction, *transaction.ScriptGroup) {
+	tx := &types.Transaction{
+		Inputs: []*types.CellInput{
+			{PreviousOutput: &types.OutPoint{TxHash: types.HexToHash("0x68777db22145ce8e55014cbfd0d52e7357068451ea539ac7df952a36a9696f02")}},
+			{PreviousOutput: &types.OutPoint{TxHash: types.HexToHash("0x68777db22145ce8e55014cbfd0d52e7357068451ea539ac7df952a36a9696f02"), Index: 1}},
+		},
+		Witnesses: [][]byte{{}, {}},
+	}
+	group := &transaction.ScriptGroup{
+		Script:       joyidaddress.DefaultJoyIDLock().FromPubkeyHash(lockKey.PubkeyHash(), lockKey.Alg).Script,
+		GroupType:    types.ScriptTypeLock,
+		InputIndices: []uint32{0, 1},
+	}
+	var err error
+	if mode == signer.NativeUnlock {
+		err = signer.SignNativeUnlockTx(tx, group, key.Signer(testWebAuthnMsg))
+	} else {
+		err = signer.SignSubkeyUnlockTx(tx, group, key.Signer(testWebAuthnMsg))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx, group
+}
+
+func errorCode(err error) ErrorCode {
+	var verifyErr *VerifyError
+	if errors.As(err, &verifyErr) {
+		return verifyErr.Code
+	}
+	return 0
+}
+
+func TestVerifyScriptGroup(t *testing.T) {
+	k1Key := signer.AlgPrivKey{PrivKey: k1PrivKey, Alg: alg.Secp256k1}
+	r1Key := signer.AlgPrivKey{PrivKey: r1PrivKey, Alg: alg.Secp256r1}
+	testcases := []struct {
+		name     string
+		key      signer.AlgPrivKey
+		lockKey  signer.AlgPrivKey
+		mode     signer.UnlockMode
+		wantCode ErrorCode
+	}{
+		{"k1 native", k1Key, k1Key, signer.NativeUnlock, 0},
+		{"r1 native", r1Key, r1Key, signer.NativeUnlock, 0},
+		{"k1 subkey", k1Key, r1Key, signer.SubkeyUnlock, 0},
+		{"r1 subkey", r1Key, k1Key, signer.SubkeyUnlock, 0},
+		{"k1 native with other lock", k1Key, signer.AlgPrivKey{PrivKey: r1PrivKey, Alg: alg.Secp256k1}, signer.NativeUnlock, ErrPubkeyHashMismatch},
+		{"r1 native with other lock", r1Key, signer.AlgPrivKey{PrivKey: k1PrivKey, Alg: alg.Secp256r1}, signer.NativeUnlock, ErrPubkeyHashMismatch},
+	}
+	for _, tc := range testcases {
+		tx, group := signedTransaction(t, tc.key, tc.lockKey, tc.mode)
+		if got := errorCode(VerifyScriptGroup(tx, group)); got != tc.wantCode {
+			t.Errorf("%s: VerifyScriptGroup() error code = %v, want %v", tc.name, got, tc.wantCode)
+		}
+	}
+}
+
+func TestVerifyTamperedTransaction(t *testing.T) {
+	for _, key := range []signer.AlgPrivKey{{PrivKey: k1PrivKey, Alg: alg.Secp256k1}, {PrivKey: r1PrivKey, Alg: alg.Secp256r1}} {
+		tx, group := signedTransaction(t, key, key, signer.NativeUnlock)
+		// the second witness of the group is covered by the sighash
+		tx.Witnesses[1] = []byte{0x01}
+		want := ErrInvalidSignature
+		if key.Alg == alg.Secp256r1 {
+			want = ErrChallengeMismatch
+		}
+		err := VerifyTransaction(&transaction.TransactionWithScriptGroups{TxView: tx, ScriptGroups: []*transaction.ScriptGroup{group}})
+		if got := errorCode(err); got != want {
+			t.Errorf("VerifyTransaction() error = %v, want code %v", err, want)
+		}
+	}
+
+	tx, group := signedTransaction(t, signer.AlgPrivKey{PrivKey: k1PrivKey, Alg: alg.Secp256k1}, signer.AlgPrivKey{PrivKey: k1PrivKey, Alg: alg.Secp256k1}, signer.NativeUnlock)
+	witnessArgs, _ := types.DeserializeWitnessArgs(tx.Witnesses[0])
+	witnessArgs.Lock[0] = 0x09
+	tx.Witnesses[0] = witnessArgs.Serialize()
+	if got := errorCode(VerifyScriptGroup(tx, group)); got != ErrUnknownUnlockMode {
+		t.Errorf("VerifyScriptGroup() error code = %v, want %v", got, ErrUnknownUnlockMode)
+	}
+}
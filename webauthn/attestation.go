@@ -0,0 +1,245 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/address"
+)
+
+// formats of the attestation statement
+const (
+	AttestationFormatNone    = "none"
+	AttestationFormatPacked  = "packed"
+	AttestationFormatFIDOU2F = "fido-u2f"
+)
+
+// types of the verified attestation
+const (
+	AttestationTypeNone  = "none"
+	AttestationTypeSelf  = "self"
+	AttestationTypeBasic = "basic"
+)
+
+// the COSE alg of ES256
+const coseAlgES256 = -7
+
+// aaguid + credentialIdLength
+const attestedCredentialMinLen = 16 + 2
+
+// AttestedCredential is the attested credential data of the authenticatorData of the registration
+type AttestedCredential struct {
+	AAGUID       []byte
+	CredentialID []byte
+	// COSEKey is the credential public key in COSE_Key format
+	COSEKey   []byte
+	PublicKey *secp256r1.PublicKey
+}
+
+// AttestationStatement is the attStmt of the packed and fido-u2f formats
+type AttestationStatement struct {
+	Alg int64    `cbor:"alg,omitempty"`
+	Sig []byte   `cbor:"sig,omitempty"`
+	X5C [][]byte `cbor:"x5c,omitempty"`
+}
+
+// AttestationObject is the CBOR attestationObject of navigator.credentials.create
+type AttestationObject struct {
+	Format       string
+	Statement    *AttestationStatement
+	RawAuthData  []byte
+	AuthData     *AuthData
+	Credential   *AttestedCredential
+	rawStatement cbor.RawMessage
+}
+
+type attestationObject struct {
+	Fmt      string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// Registration is the verified registration of the passkey
+type Registration struct {
+	AuthData        *AuthData
+	ClientData      *ClientData
+	Credential      *AttestedCredential
+	AttestationType string
+}
+
+// ParseAttestedCredential parses the attested credential data at the beginning of the extra of authData,
+// and the credential public key must be an EC2 P-256 key
+func ParseAttestedCredential(data []byte) (*AttestedCredential, []byte, error) {
+	if len(data) < attestedCredentialMinLen {
+		return nil, nil, errors.New("attested credential data is too short")
+	}
+	idLen := int(binary.BigEndian.Uint16(data[16:18]))
+	if len(data) < attestedCredentialMinLen+idLen {
+		return nil, nil, errors.New("credential id of the attested credential data is too short")
+	}
+	var coseKey cbor.RawMessage
+	rest, err := cbor.UnmarshalFirst(data[attestedCredentialMinLen+idLen:], &coseKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	pubkey, err := secp256r1.ParsePublicKeyCOSE(coseKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &AttestedCredential{
+		AAGUID:       data[:16],
+		CredentialID: data[attestedCredentialMinLen : attestedCredentialMinLen+idLen],
+		COSEKey:      []byte(coseKey),
+		PublicKey:    pubkey,
+	}, rest, nil
+}
+
+// ParseAttestationObject decodes the attestationObject and the attested credential data of its authData
+func ParseAttestationObject(data []byte) (*AttestationObject, error) {
+	var raw attestationObject
+	if err := cbor.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid attestationObject: %w", err)
+	}
+	authData, err := ParseAuthData(raw.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if !authData.HasAttestedCredentialData() {
+		return nil, errors.New("authData of the attestation has no attested credential data")
+	}
+	credential, rest, err := ParseAttestedCredential(authData.Extra)
+	if err != nil {
+		return nil, err
+	}
+	if authData.HasExtensionData() {
+		if err := cbor.Wellformed(rest); err != nil {
+			return nil, fmt.Errorf("invalid extensions of authData: %w", err)
+		}
+	} else if len(rest) > 0 {
+		return nil, errors.New("authData has trailing bytes after the credential public key")
+	}
+	statement := &AttestationStatement{}
+	if len(raw.AttStmt) > 0 {
+		if err := cbor.Unmarshal(raw.AttStmt, statement); err != nil {
+			return nil, fmt.Errorf("invalid attStmt: %w", err)
+		}
+	}
+	return &AttestationObject{
+		Format:       raw.Fmt,
+		Statement:    statement,
+		RawAuthData:  raw.AuthData,
+		AuthData:     authData,
+		Credential:   credential,
+		rawStatement: raw.AttStmt,
+	}, nil
+}
+
+// VerifyStatement checks the attestation statement over authData and the hash of clientDataJSON, and
+// returns the attestation type. The certificate chain of the basic attestation isn't checked against
+// the trust anchors.
+func (o *AttestationObject) VerifyStatement(clientDataHash []byte) (string, error) {
+	switch o.Format {
+	case AttestationFormatNone:
+		if !isEmptyMap(o.rawStatement) {
+			return "", errors.New("attStmt of none attestation must be empty")
+		}
+		return AttestationTypeNone, nil
+	case AttestationFormatPacked:
+		if o.Statement.Alg != coseAlgES256 {
+			return "", fmt.Errorf("alg %d of packed attestation isn't ES256", o.Statement.Alg)
+		}
+		signData := append(append([]byte{}, o.RawAuthData...), clientDataHash...)
+		if len(o.Statement.X5C) == 0 {
+			if !ecdsa.VerifyASN1(o.Credential.PublicKey.ECDSA(), sha256.Sha256(signData), o.Statement.Sig) {
+				return "", errors.New("invalid signature of packed self attestation")
+			}
+			return AttestationTypeSelf, nil
+		}
+		pubkey, err := certificatePublicKey(o.Statement.X5C[0])
+		if err != nil {
+			return "", err
+		}
+		if !ecdsa.VerifyASN1(pubkey, sha256.Sha256(signData), o.Statement.Sig) {
+			return "", errors.New("invalid signature of packed attestation")
+		}
+		return AttestationTypeBasic, nil
+	case AttestationFormatFIDOU2F:
+		if len(o.Statement.X5C) != 1 {
+			return "", errors.New("fido-u2f attestation must have exactly one certificate")
+		}
+		pubkey, err := certificatePublicKey(o.Statement.X5C[0])
+		if err != nil {
+			return "", err
+		}
+		// 0x00 | rpIdHash | clientDataHash | credentialId | uncompressed public key
+		signData := []byte{0x00}
+		signData = append(signData, o.AuthData.RPIDHash...)
+		signData = append(signData, clientDataHash...)
+		signData = append(signData, o.Credential.CredentialID...)
+		signData = append(signData, o.Credential.PublicKey.Uncompressed()...)
+		if !ecdsa.VerifyASN1(pubkey, sha256.Sha256(signData), o.Statement.Sig) {
+			return "", errors.New("invalid signature of fido-u2f attestation")
+		}
+		return AttestationTypeBasic, nil
+	default:
+		return "", fmt.Errorf("unsupported attestation format %s", o.Format)
+	}
+}
+
+// VerifyRegistration parses the attestationObject and clientDataJSON of navigator.credentials.create,
+// and checks them against the challenge, the options and the attestation statement
+func (o *VerifyOptions) VerifyRegistration(attestationObject, clientDataJSON []byte, challenge string) (*Registration, error) {
+	attestation, err := ParseAttestationObject(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	authData, clientData, err := o.verify(attestation.RawAuthData, clientDataJSON, challenge, ClientDataTypeCreate)
+	if err != nil {
+		return nil, err
+	}
+	attestationType, err := attestation.VerifyStatement(sha256.Sha256(clientDataJSON))
+	if err != nil {
+		return nil, err
+	}
+	return &Registration{
+		AuthData:        authData,
+		ClientData:      clientData,
+		Credential:      attestation.Credential,
+		AttestationType: attestationType,
+	}, nil
+}
+
+// JoyIDAddress returns the JoyID address of the credential public key, and the default JoyID lock
+// is used if addr is nil
+func (r *Registration) JoyIDAddress(addr *joyidaddress.JoyIDAddress) *address.Address {
+	if addr == nil {
+		addr = joyidaddress.DefaultJoyIDLock()
+	}
+	return addr.FromPublicKey(r.Credential.PublicKey)
+}
+
+func certificatePublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation certificate: %w", err)
+	}
+	pubkey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pubkey.Curve != elliptic.P256() {
+		return nil, errors.New("public key of the attestation certificate must be P-256")
+	}
+	return pubkey, nil
+}
+
+func isEmptyMap(raw cbor.RawMessage) bool {
+	// 0xa0 is the CBOR empty map
+	return len(raw) == 0 || bytes.Equal(raw, []byte{0xa0})
+}
@@ -0,0 +1,167 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	joyidaddress "github.com/nervina-labs/joyid-sdk-go/address"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+)
+
+type testRegistration struct {
+	credentialKey  *secp256r1.Key
+	attestationKey *ecdsa.PrivateKey
+	certificate    []byte
+	credentialID   []byte
+	authData       []byte
+	clientDataJSON []byte
+}
+
+func newTestRegistration(t *testing.T) *testRegistration {
+	credentialKey := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	pubkey := credentialKey.PublicKey().Bytes()
+	coseKey, err := cbor.Marshal(map[int]interface{}{1: 2, 3: -7, -1: 1, -2: pubkey[:32], -3: pubkey[32:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := []byte("joyid-credential")
+	authData := &AuthData{
+		RPIDHash: RPIDHash("localhost"),
+		Flags:    FlagUserPresent | FlagUserVerified | FlagAttestedCredentialData,
+	}
+	authData.Extra = make([]byte, 16)
+	authData.Extra = binary.BigEndian.AppendUint16(authData.Extra, uint16(len(credentialID)))
+	authData.Extra = append(authData.Extra, credentialID...)
+	authData.Extra = append(authData.Extra, coseKey...)
+	clientDataJSON, err := (&ClientData{Type: ClientDataTypeCreate, Challenge: "abc", Origin: "http://localhost:8000"}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attestationKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test Authenticator Attestation"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &attestationKey.PublicKey, attestationKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRegistration{
+		credentialKey:  credentialKey,
+		attestationKey: attestationKey,
+		certificate:    certificate,
+		credentialID:   credentialID,
+		authData:       authData.Serialize(),
+		clientDataJSON: clientDataJSON,
+	}
+}
+
+func (r *testRegistration) attestationObject(t *testing.T, format string, attStmt map[string]interface{}) []byte {
+	data, err := cbor.Marshal(map[string]interface{}{"fmt": format, "attStmt": attStmt, "authData": r.authData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (r *testRegistration) sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sha256.Sha256(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	r := newTestRegistration(t)
+	clientDataHash := sha256.Sha256(r.clientDataJSON)
+	packedData := append(append([]byte{}, r.authData...), clientDataHash...)
+	u2fData := append([]byte{0x00}, RPIDHash("localhost")...)
+	u2fData = append(u2fData, clientDataHash...)
+	u2fData = append(u2fData, r.credentialID...)
+	u2fData = append(u2fData, r.credentialKey.PublicKey().Uncompressed()...)
+
+	tests := []struct {
+		name    string
+		format  string
+		attStmt map[string]interface{}
+		want    string
+	}{
+		{"none", AttestationFormatNone, map[string]interface{}{}, AttestationTypeNone},
+		{"packed self", AttestationFormatPacked, map[string]interface{}{"alg": -7, "sig": r.sign(t, r.credentialKey.PrivateKey, packedData)}, AttestationTypeSelf},
+		{"packed basic", AttestationFormatPacked, map[string]interface{}{"alg": -7, "sig": r.sign(t, r.attestationKey, packedData), "x5c": [][]byte{r.certificate}}, AttestationTypeBasic},
+		{"fido-u2f", AttestationFormatFIDOU2F, map[string]interface{}{"sig": r.sign(t, r.attestationKey, u2fData), "x5c": [][]byte{r.certificate}}, AttestationTypeBasic},
+	}
+	options := &VerifyOptions{RPID: "localhost", Origins: []string{"http://localhost:8000"}, RequireUserVerification: true}
+	wantAddr, _ := joyidaddress.DefaultJoyIDLock().FromPubkeyHash(r.credentialKey.PubkeyHash(), alg.Secp256r1).Encode()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registration, err := options.VerifyRegistration(r.attestationObject(t, tt.format, tt.attStmt), r.clientDataJSON, "abc")
+			if err != nil {
+				t.Fatalf("VerifyRegistration() error = %v", err)
+			}
+			if registration.AttestationType != tt.want {
+				t.Errorf("AttestationType = %s, want %s", registration.AttestationType, tt.want)
+			}
+			if string(registration.Credential.CredentialID) != string(r.credentialID) {
+				t.Errorf("CredentialID = %s, want %s", registration.Credential.CredentialID, r.credentialID)
+			}
+			if got, _ := registration.JoyIDAddress(nil).Encode(); got != wantAddr {
+				t.Errorf("JoyIDAddress() = %s, want %s", got, wantAddr)
+			}
+		})
+	}
+}
+
+func TestVerifyInvalidRegistration(t *testing.T) {
+	r := newTestRegistration(t)
+	clientDataHash := sha256.Sha256(r.clientDataJSON)
+	packedData := append(append([]byte{}, r.authData...), clientDataHash...)
+	// the signature of the attestation key without the certificate
+	wrongSelfSig := r.sign(t, r.attestationKey, packedData)
+	getClientData, err := NewClientData("abc", "http://localhost:8000").Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name              string
+		attestationObject []byte
+		clientDataJSON    []byte
+		challenge         string
+	}{
+		{"wrong self signature", r.attestationObject(t, AttestationFormatPacked, map[string]interface{}{"alg": -7, "sig": wrongSelfSig}), r.clientDataJSON, "abc"},
+		{"RS256 packed", r.attestationObject(t, AttestationFormatPacked, map[string]interface{}{"alg": -257, "sig": wrongSelfSig}), r.clientDataJSON, "abc"},
+		{"fido-u2f without certificate", r.attestationObject(t, AttestationFormatFIDOU2F, map[string]interface{}{"sig": wrongSelfSig}), r.clientDataJSON, "abc"},
+		{"none with statement", r.attestationObject(t, AttestationFormatNone, map[string]interface{}{"sig": wrongSelfSig}), r.clientDataJSON, "abc"},
+		{"unsupported format", r.attestationObject(t, "tpm", map[string]interface{}{}), r.clientDataJSON, "abc"},
+		{"wrong challenge", r.attestationObject(t, AttestationFormatNone, map[string]interface{}{}), r.clientDataJSON, "abd"},
+		{"webauthn.get clientData", r.attestationObject(t, AttestationFormatNone, map[string]interface{}{}), getClientData, "abc"},
+		{"invalid CBOR", []byte{0xa3, 0x01}, r.clientDataJSON, "abc"},
+	}
+	options := &VerifyOptions{RPID: "localhost"}
+	for _, tt := range tests {
+		if _, err := options.VerifyRegistration(tt.attestationObject, tt.clientDataJSON, tt.challenge); err == nil {
+			t.Errorf("%s: VerifyRegistration() should fail", tt.name)
+		}
+	}
+
+	// authData without attested credential data
+	r.authData = (&AuthData{RPIDHash: RPIDHash("localhost"), Flags: FlagUserPresent}).Serialize()
+	if _, err := ParseAttestationObject(r.attestationObject(t, AttestationFormatNone, map[string]interface{}{})); err == nil {
+		t.Errorf("ParseAttestationObject() should fail without attested credential data")
+	}
+}
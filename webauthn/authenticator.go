@@ -0,0 +1,179 @@
+package webauthn
+
+import (
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+)
+
+// Authenticator is the software authenticator of the secp256r1 key, which produces the assertions and
+// the attestations in the same way as the passkey in the browser for tests and server-side accounts
+type Authenticator struct {
+	Key         *secp256r1.Key
+	RPID        string
+	Origin      string
+	CrossOrigin bool
+	// UserVerification sets the UV flag besides the UP flag
+	UserVerification bool
+	AAGUID           []byte
+	CredentialID     []byte
+	// AttestationFormat is none or packed self attestation, and none is used if it is empty
+	AttestationFormat string
+
+	mu        sync.Mutex
+	signCount uint32
+}
+
+// Assertion is the response of navigator.credentials.get
+type Assertion struct {
+	CredentialID      []byte
+	AuthenticatorData []byte
+	ClientDataJSON    []byte
+	// Signature is the ASN.1 DER signature of authenticatorData | sha256(clientDataJSON)
+	Signature []byte
+}
+
+// Attestation is the response of navigator.credentials.create
+type Attestation struct {
+	CredentialID      []byte
+	AttestationObject []byte
+	ClientDataJSON    []byte
+}
+
+// NewAuthenticator returns the user-verifying authenticator of the key for the RP ID and the origin,
+// and the credential id is the blake160 pubkey hash of the key
+func NewAuthenticator(key *secp256r1.Key, rpID, origin string) *Authenticator {
+	return &Authenticator{
+		Key:              key,
+		RPID:             rpID,
+		Origin:           origin,
+		UserVerification: true,
+		AAGUID:           make([]byte, 16),
+		CredentialID:     key.PubkeyHash(),
+	}
+}
+
+// SignCount returns the signature counter of the last assertion or attestation
+func (a *Authenticator) SignCount() uint32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.signCount
+}
+
+// GetAssertion signs the challenge bytes whose base64url is the challenge of clientDataJSON, and the
+// signature counter is incremented
+func (a *Authenticator) GetAssertion(challenge []byte) (*Assertion, error) {
+	clientDataJSON, err := a.clientData(ClientDataTypeGet, challenge)
+	if err != nil {
+		return nil, err
+	}
+	authData := a.authData(0)
+	signature, err := a.sign(authData, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &Assertion{
+		CredentialID:      a.CredentialID,
+		AuthenticatorData: authData,
+		ClientDataJSON:    clientDataJSON,
+		Signature:         signature,
+	}, nil
+}
+
+// MakeCredential creates the attestation of the credential for the challenge bytes of the registration
+func (a *Authenticator) MakeCredential(challenge []byte) (*Attestation, error) {
+	if len(a.CredentialID) > 0xffff || len(a.AAGUID) != 16 {
+		return nil, errors.New("AAGUID must be 16 bytes and credential id must be shorter than 65536 bytes")
+	}
+	publicKey := a.Key.PublicKey()
+	if publicKey == nil {
+		return nil, errors.New("key of the authenticator is invalid")
+	}
+	pubkey := publicKey.Bytes()
+	coseKey, err := cbor.Marshal(map[int]interface{}{1: 2, 3: coseAlgES256, -1: 1, -2: pubkey[:32], -3: pubkey[32:]})
+	if err != nil {
+		return nil, err
+	}
+	credential := append([]byte{}, a.AAGUID...)
+	credential = binary.BigEndian.AppendUint16(credential, uint16(len(a.CredentialID)))
+	credential = append(credential, a.CredentialID...)
+	credential = append(credential, coseKey...)
+	clientDataJSON, err := a.clientData(ClientDataTypeCreate, challenge)
+	if err != nil {
+		return nil, err
+	}
+	authData := append(a.authData(FlagAttestedCredentialData), credential...)
+
+	attStmt := map[string]interface{}{}
+	switch a.AttestationFormat {
+	case "", AttestationFormatNone:
+	case AttestationFormatPacked:
+		signature, err := a.sign(authData, clientDataJSON)
+		if err != nil {
+			return nil, err
+		}
+		attStmt["alg"] = coseAlgES256
+		attStmt["sig"] = signature
+	default:
+		return nil, fmt.Errorf("unsupported attestation format %s", a.AttestationFormat)
+	}
+	format := a.AttestationFormat
+	if format == "" {
+		format = AttestationFormatNone
+	}
+	attestationObject, err := cbor.Marshal(map[string]interface{}{"fmt": format, "attStmt": attStmt, "authData": authData})
+	if err != nil {
+		return nil, err
+	}
+	return &Attestation{
+		CredentialID:      a.CredentialID,
+		AttestationObject: attestationObject,
+		ClientDataJSON:    clientDataJSON,
+	}, nil
+}
+
+// authData increments the signature counter and returns authenticatorData without extra
+func (a *Authenticator) authData(flags byte) []byte {
+	a.mu.Lock()
+	a.signCount++
+	signCount := a.signCount
+	a.mu.Unlock()
+
+	flags |= FlagUserPresent
+	if a.UserVerification {
+		flags |= FlagUserVerified
+	}
+	authData := &AuthData{RPIDHash: RPIDHash(a.RPID), Flags: flags, SignCount: signCount}
+	return authData.Serialize()
+}
+
+func (a *Authenticator) clientData(clientDataType string, challenge []byte) ([]byte, error) {
+	clientData := &ClientData{
+		Type:        clientDataType,
+		Challenge:   base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:      a.Origin,
+		CrossOrigin: a.CrossOrigin,
+	}
+	return clientData.Serialize()
+}
+
+func (a *Authenticator) sign(authData, clientDataJSON []byte) ([]byte, error) {
+	if a.Key == nil || a.Key.PrivateKey == nil || a.Key.PrivateKey.D == nil {
+		return nil, errors.New("key of the authenticator cannot be empty")
+	}
+	signData := append(append([]byte{}, authData...), sha256.Sha256(clientDataJSON)...)
+	// Key.Sign is deterministic if the key is, and its low S signature is encoded as DER
+	sig := a.Key.Sign(sha256.Sha256(signData))
+	if len(sig) != 64 {
+		return nil, errors.New("secp256r1 sign error")
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])})
+}
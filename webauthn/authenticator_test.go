@@ -0,0 +1,117 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/secp256r1"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+)
+
+func TestAuthenticatorGetAssertion(t *testing.T) {
+	key := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	authenticator := NewAuthenticator(key, "localhost", `http://localhost:8000/"joy"`)
+	authenticator.CrossOrigin = true
+	challenge := []byte("joyid challenge")
+	options := &VerifyOptions{RPID: "localhost", Origins: []string{`http://localhost:8000/"joy"`}, RequireUserVerification: true}
+
+	for i := uint32(1); i <= 2; i++ {
+		assertion, err := authenticator.GetAssertion(challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authData, clientData, err := options.Verify(assertion.AuthenticatorData, assertion.ClientDataJSON, base64.RawURLEncoding.EncodeToString(challenge))
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if authData.SignCount != i || authenticator.SignCount() != i {
+			t.Errorf("SignCount = %d, want %d", authData.SignCount, i)
+		}
+		if !clientData.CrossOrigin {
+			t.Errorf("CrossOrigin = false, want true")
+		}
+		if !strings.Contains(string(assertion.ClientDataJSON), `"origin":"http://localhost:8000/\"joy\""`) {
+			t.Errorf("ClientDataJSON = %s, origin isn't escaped", assertion.ClientDataJSON)
+		}
+		signData := append(append([]byte{}, assertion.AuthenticatorData...), sha256.Sha256(assertion.ClientDataJSON)...)
+		if !ecdsa.VerifyASN1(key.PublicKey().ECDSA(), sha256.Sha256(signData), assertion.Signature) {
+			t.Errorf("Signature of the assertion is invalid")
+		}
+	}
+
+	authenticator.UserVerification = false
+	assertion, _ := authenticator.GetAssertion(challenge)
+	if _, _, err := options.Verify(assertion.AuthenticatorData, assertion.ClientDataJSON, base64.RawURLEncoding.EncodeToString(challenge)); err == nil {
+		t.Errorf("Verify() should fail without user verification")
+	}
+}
+
+func TestAuthenticatorMakeCredential(t *testing.T) {
+	key := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	options := &VerifyOptions{RPID: "localhost", Origins: []string{"http://localhost:8000"}, RequireUserVerification: true}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", AttestationTypeNone},
+		{AttestationFormatNone, AttestationTypeNone},
+		{AttestationFormatPacked, AttestationTypeSelf},
+	}
+	for _, tt := range tests {
+		authenticator := NewAuthenticator(key, "localhost", "http://localhost:8000")
+		authenticator.AttestationFormat = tt.format
+		attestation, err := authenticator.MakeCredential([]byte("abc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		registration, err := options.VerifyRegistration(attestation.AttestationObject, attestation.ClientDataJSON, base64.RawURLEncoding.EncodeToString([]byte("abc")))
+		if err != nil {
+			t.Fatalf("%q: VerifyRegistration() error = %v", tt.format, err)
+		}
+		if registration.AttestationType != tt.want {
+			t.Errorf("%q: AttestationType = %s, want %s", tt.format, registration.AttestationType, tt.want)
+		}
+		if string(registration.Credential.CredentialID) != string(key.PubkeyHash()) {
+			t.Errorf("%q: CredentialID = %x, want %x", tt.format, registration.Credential.CredentialID, key.PubkeyHash())
+		}
+		if string(registration.Credential.PublicKey.Bytes()) != string(key.PublicKey().Bytes()) {
+			t.Errorf("%q: credential public key = %x, want %x", tt.format, registration.Credential.PublicKey.Bytes(), key.PublicKey().Bytes())
+		}
+	}
+
+	authenticator := NewAuthenticator(key, "localhost", "http://localhost:8000")
+	authenticator.AttestationFormat = AttestationFormatFIDOU2F
+	if _, err := authenticator.MakeCredential([]byte("abc")); err == nil {
+		t.Errorf("MakeCredential() should fail with fido-u2f format")
+	}
+	if _, err := NewAuthenticator(secp256r1.ImportKey("zz"), "localhost", "http://localhost:8000").MakeCredential([]byte("abc")); err == nil {
+		t.Errorf("MakeCredential() should fail with invalid key")
+	}
+}
+
+func TestAuthenticatorClientData(t *testing.T) {
+	key := secp256r1.ImportKey("0x4271c23380932c74a041b4f56779e5ef60e808a127825875f906260f1f657761")
+	authenticator := NewAuthenticator(key, "localhost", "https://例え.jp/\t <joy>")
+	assertion, err := authenticator.GetAssertion([]byte("joyid challenge"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the clientDataJSON of the browser for the same origin
+	want := "{\"type\":\"webauthn.get\",\"challenge\":\"am95aWQgY2hhbGxlbmdl\",\"origin\":\"https://例え.jp/\\u0009 <joy>\",\"crossOrigin\":false}"
+	if string(assertion.ClientDataJSON) != want {
+		t.Errorf("ClientDataJSON = %s, want %s", assertion.ClientDataJSON, want)
+	}
+
+	authenticator.Origin = "https://\xff"
+	if _, err := authenticator.GetAssertion([]byte("joyid challenge")); err == nil {
+		t.Errorf("GetAssertion() should fail with invalid UTF-8 origin")
+	}
+	if _, err := authenticator.MakeCredential([]byte("joyid challenge")); err == nil {
+		t.Errorf("MakeCredential() should fail with invalid UTF-8 origin")
+	}
+	if got := authenticator.SignCount(); got != 1 {
+		t.Errorf("SignCount() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,218 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/sha256"
+)
+
+const (
+	ClientDataTypeGet    = "webauthn.get"
+	ClientDataTypeCreate = "webauthn.create"
+
+	// rpIdHash + flags + signCount
+	AuthDataMinLen = 37
+)
+
+// flags of authenticatorData
+const (
+	FlagUserPresent            byte = 0x01
+	FlagUserVerified           byte = 0x04
+	FlagAttestedCredentialData byte = 0x40
+	FlagExtensionData          byte = 0x80
+)
+
+// AuthData is the authenticatorData of WebAuthn
+type AuthData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+	// Extra is the attested credential data and extensions which are present with AT or ED flag
+	Extra []byte
+}
+
+// ClientData is the clientDataJSON of WebAuthn
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ParseAuthData parses the authenticatorData of WebAuthn
+func ParseAuthData(data []byte) (*AuthData, error) {
+	if len(data) < AuthDataMinLen {
+		return nil, fmt.Errorf("authData must be at least %d bytes", AuthDataMinLen)
+	}
+	authData := &AuthData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+		Extra:     data[37:],
+	}
+	hasExtra := authData.HasAttestedCredentialData() || authData.HasExtensionData()
+	if hasExtra && len(authData.Extra) == 0 {
+		return nil, errors.New("authData is missing attested credential data or extensions")
+	}
+	if !hasExtra && len(authData.Extra) > 0 {
+		return nil, errors.New("authData has trailing bytes")
+	}
+	return authData, nil
+}
+
+func (a *AuthData) UserPresent() bool {
+	return a.Flags&FlagUserPresent != 0
+}
+
+func (a *AuthData) UserVerified() bool {
+	return a.Flags&FlagUserVerified != 0
+}
+
+func (a *AuthData) HasAttestedCredentialData() bool {
+	return a.Flags&FlagAttestedCredentialData != 0
+}
+
+func (a *AuthData) HasExtensionData() bool {
+	return a.Flags&FlagExtensionData != 0
+}
+
+// Serialize returns rpIdHash | flags | signCount | extra
+func (a *AuthData) Serialize() []byte {
+	data := append([]byte{}, a.RPIDHash...)
+	data = append(data, a.Flags)
+	data = binary.BigEndian.AppendUint32(data, a.SignCount)
+	return append(data, a.Extra...)
+}
+
+// NewClientData returns the clientData of webauthn.get which is not cross-origin
+func NewClientData(challenge, origin string) *ClientData {
+	return &ClientData{
+		Type:      ClientDataTypeGet,
+		Challenge: challenge,
+		Origin:    origin,
+	}
+}
+
+// ParseClientData parses the clientDataJSON of WebAuthn
+func ParseClientData(data []byte) (*ClientData, error) {
+	var clientData ClientData
+	if err := json.Unmarshal(data, &clientData); err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON: %v", err)
+	}
+	if clientData.Type == "" || clientData.Challenge == "" {
+		return nil, errors.New("clientDataJSON must have type and challenge")
+	}
+	return &clientData, nil
+}
+
+// Serialize returns clientDataJSON in the same way as the browser, which is the serialization of
+// CollectedClientData in the WebAuthn spec with the strings encoded by CCDToString
+func (c *ClientData) Serialize() ([]byte, error) {
+	fields := []struct{ name, value string }{{"type", c.Type}, {"challenge", c.Challenge}, {"origin", c.Origin}}
+	data := []byte{'{'}
+	for i, field := range fields {
+		value, err := CCDToString(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s of clientData: %w", field.name, err)
+		}
+		if i > 0 {
+			data = append(data, ',')
+		}
+		data = append(data, `"`+field.name+`":`...)
+		data = append(data, value...)
+	}
+	data = append(data, `,"crossOrigin":`...)
+	data = strconv.AppendBool(data, c.CrossOrigin)
+	return append(data, '}'), nil
+}
+
+// CCDToString encodes the string of clientDataJSON as the WebAuthn spec, which only escapes the quote,
+// the backslash and the control characters as \u00xx unlike encoding/json, and the string must be
+// valid UTF-8
+func CCDToString(s string) ([]byte, error) {
+	if !utf8.ValidString(s) {
+		return nil, errors.New("string must be valid UTF-8")
+	}
+	encoded := make([]byte, 0, len(s)+2)
+	encoded = append(encoded, '"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			encoded = append(encoded, `\"`...)
+		case r == '\\':
+			encoded = append(encoded, `\\`...)
+		case r < 0x20:
+			encoded = append(encoded, fmt.Sprintf(`\u%04x`, r)...)
+		default:
+			encoded = utf8.AppendRune(encoded, r)
+		}
+	}
+	return append(encoded, '"'), nil
+}
+
+// RPIDHash returns the sha256 of the relying party id
+func RPIDHash(rpID string) []byte {
+	return sha256.Sha256([]byte(rpID))
+}
+
+// VerifyOptions are the expectations of the relying party for the WebAuthn assertion and registration,
+// and the empty RPID or Origins are not checked
+type VerifyOptions struct {
+	RPID                    string
+	Origins                 []string
+	RequireUserVerification bool
+}
+
+// Verify parses authData and clientDataJSON of the assertion, and checks them against the challenge
+// and the options. The user presence flag is always required.
+func (o *VerifyOptions) Verify(authData, clientDataJSON []byte, challenge string) (*AuthData, *ClientData, error) {
+	return o.verify(authData, clientDataJSON, challenge, ClientDataTypeGet)
+}
+
+func (o *VerifyOptions) verify(authData, clientDataJSON []byte, challenge, clientDataType string) (*AuthData, *ClientData, error) {
+	parsedAuthData, err := ParseAuthData(authData)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientData, err := ParseClientData(clientDataJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	if clientData.Type != clientDataType {
+		return nil, nil, fmt.Errorf("clientData type %s, want %s", clientData.Type, clientDataType)
+	}
+	if clientData.Challenge != challenge {
+		return nil, nil, fmt.Errorf("challenge of clientData %s doesn't match %s", clientData.Challenge, challenge)
+	}
+	if !parsedAuthData.UserPresent() {
+		return nil, nil, errors.New("user is not present")
+	}
+	if o == nil {
+		return parsedAuthData, clientData, nil
+	}
+	if o.RequireUserVerification && !parsedAuthData.UserVerified() {
+		return nil, nil, errors.New("user is not verified")
+	}
+	if o.RPID != "" && !bytes.Equal(parsedAuthData.RPIDHash, RPIDHash(o.RPID)) {
+		return nil, nil, fmt.Errorf("rpIdHash %x doesn't match RP ID %s", parsedAuthData.RPIDHash, o.RPID)
+	}
+	if len(o.Origins) > 0 && !o.allowOrigin(clientData.Origin) {
+		return nil, nil, fmt.Errorf("origin %s is not allowed", clientData.Origin)
+	}
+	return parsedAuthData, clientData, nil
+}
+
+func (o *VerifyOptions) allowOrigin(origin string) bool {
+	for _, allowed := range o.Origins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
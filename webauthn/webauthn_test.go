@@ -0,0 +1,131 @@
+package webauthn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/utils"
+)
+
+const testAuthData = "49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630162f9fb77"
+
+func TestParseAuthData(t *testing.T) {
+	data, _ := utils.HexToBytes(testAuthData)
+	authData, err := ParseAuthData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(authData.RPIDHash, RPIDHash("localhost")) {
+		t.Errorf("RPIDHash = %x, want sha256 of localhost", authData.RPIDHash)
+	}
+	if !authData.UserPresent() || authData.UserVerified() || authData.HasAttestedCredentialData() {
+		t.Errorf("Flags = %#x, want %#x", authData.Flags, FlagUserPresent)
+	}
+	if authData.SignCount != 0x62f9fb77 {
+		t.Errorf("SignCount = %#x, want %#x", authData.SignCount, 0x62f9fb77)
+	}
+	if got := utils.BytesToHex(authData.Serialize()); got != testAuthData {
+		t.Errorf("Serialize() = %s, want %s", got, testAuthData)
+	}
+
+	if _, err := ParseAuthData(data[:36]); err == nil {
+		t.Errorf("ParseAuthData() should fail with short authData")
+	}
+	if _, err := ParseAuthData(append(data, 0x00)); err == nil {
+		t.Errorf("ParseAuthData() should fail with trailing bytes")
+	}
+	data[32] |= FlagExtensionData
+	if _, err := ParseAuthData(data); err == nil {
+		t.Errorf("ParseAuthData() should fail without extensions")
+	}
+}
+
+func TestClientDataSerialize(t *testing.T) {
+	want := `{"type":"webauthn.get","challenge":"abc","origin":"http://localhost:8000","crossOrigin":false}`
+	clientData := NewClientData("abc", "http://localhost:8000")
+	if got, err := clientData.Serialize(); err != nil || string(got) != want {
+		t.Errorf("Serialize() = %s, %v, want %s", got, err, want)
+	}
+	parsed, err := ParseClientData([]byte(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *parsed != *clientData {
+		t.Errorf("ParseClientData() = %+v, want %+v", parsed, clientData)
+	}
+	if _, err := ParseClientData([]byte(`{"origin":"http://localhost:8000"}`)); err == nil {
+		t.Errorf("ParseClientData() should fail without type and challenge")
+	}
+}
+
+func TestCCDToString(t *testing.T) {
+	// the browser only escapes the quote, the backslash and the control characters with lowercase hex,
+	// while encoding/json writes \n and \t, escapes U+2028, U+2029 and HTML, and replaces invalid UTF-8
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"abc", `"abc"`},
+		{`a"b\c`, `"a\"b\\c"`},
+		{"line\nbreak\ttab\r\x00\x1f", `"line\u000abreak\u0009tab\u000d\u0000\u001f"`},
+		{"\x7f<&>", "\"\x7f<&>\""},
+		{"https://例え.jp", `"https://例え.jp"`},
+		{"\u2028\u2029😀", "\"\u2028\u2029😀\""},
+	}
+	for _, tt := range tests {
+		if got, err := CCDToString(tt.in); err != nil || string(got) != tt.want {
+			t.Errorf("CCDToString(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := CCDToString("\xff"); err == nil {
+		t.Errorf("CCDToString() should fail with invalid UTF-8")
+	}
+
+	clientData := &ClientData{Type: ClientDataTypeGet, Challenge: "a\nb", Origin: "https://例え.jp\u2028", CrossOrigin: true}
+	want := "{\"type\":\"webauthn.get\",\"challenge\":\"a\\u000ab\",\"origin\":\"https://例え.jp\u2028\",\"crossOrigin\":true}"
+	if got, err := clientData.Serialize(); err != nil || string(got) != want {
+		t.Errorf("Serialize() = %s, %v, want %s", got, err, want)
+	}
+	clientData.Origin = "https://\xff"
+	if _, err := clientData.Serialize(); err == nil {
+		t.Errorf("Serialize() should fail with invalid UTF-8 origin")
+	}
+}
+
+func TestVerifyOptions(t *testing.T) {
+	authData, _ := utils.HexToBytes(testAuthData)
+	clientData, err := NewClientData("abc", "http://localhost:8000").Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		options   *VerifyOptions
+		challenge string
+		wantErr   bool
+	}{
+		{"nil options", nil, "abc", false},
+		{"matched", &VerifyOptions{RPID: "localhost", Origins: []string{"https://app.joy.id", "http://localhost:8000"}}, "abc", false},
+		{"wrong challenge", nil, "abd", true},
+		{"wrong RP ID", &VerifyOptions{RPID: "app.joy.id"}, "abc", true},
+		{"wrong origin", &VerifyOptions{Origins: []string{"https://app.joy.id"}}, "abc", true},
+		{"user verification", &VerifyOptions{RequireUserVerification: true}, "abc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.options.Verify(authData, clientData, tt.challenge)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	create, err := (&ClientData{Type: ClientDataTypeCreate, Challenge: "abc", Origin: "http://localhost:8000"}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := (&VerifyOptions{}).Verify(authData, create, "abc"); err == nil {
+		t.Errorf("Verify() should fail with webauthn.create")
+	}
+}
@@ -0,0 +1,256 @@
+package witness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+	"github.com/nervosnetwork/ckb-sdk-go/v2/types"
+)
+
+const (
+	// unlock_mode + pubkey_hash + signature
+	Secp256k1LockLen = 86
+	// unlock_mode + pubkey + signature, and authData and clientData are appended
+	Secp256r1LockPrefixLen = 129
+	// rpIdHash + flags + signCount of authData without attested credential data and extensions
+	AuthDataLen = 37
+
+	pubkeyHashLen      = 20
+	secp256r1PubkeyLen = 64
+	// attested credential data and extensions flags of authData
+	authDataExtFlags = 0x40 | 0x80
+)
+
+// UnlockMode is the first byte of the JoyID witness lock
+type UnlockMode byte
+
+const (
+	NativeUnlock UnlockMode = 1
+	SubkeyUnlock UnlockMode = 2
+)
+
+// JoyIDLock is the lock field of WitnessArgs to unlock the JoyID lock script
+type JoyIDLock interface {
+	UnlockMode() UnlockMode
+	Alg() alg.AlgIndex
+	Serialize() []byte
+}
+
+// Secp256k1Lock: unlock_mode | keccak160(pubkey) | recoverable signature
+type Secp256k1Lock struct {
+	Mode       UnlockMode
+	PubkeyHash []byte
+	Signature  []byte
+}
+
+// Secp256r1Lock: unlock_mode | pubkey | r|s signature | authData | clientData
+type Secp256r1Lock struct {
+	Mode       UnlockMode
+	Pubkey     []byte
+	Signature  []byte
+	AuthData   []byte
+	ClientData []byte
+}
+
+// Placeholder returns the zero witness lock with the same length as the signed lock, and webAuthnMsgLen
+// is the total length of authData and clientData which is only used by secp256r1
+func Placeholder(algIndex alg.AlgIndex, webAuthnMsgLen int) []byte {
+	if algIndex == alg.Secp256r1 {
+		return make([]byte, Secp256r1LockPrefixLen+webAuthnMsgLen)
+	}
+	return make([]byte, Secp256k1LockLen)
+}
+
+func checkUnlockMode(mode UnlockMode) error {
+	switch mode {
+	case NativeUnlock, SubkeyUnlock:
+		return nil
+	default:
+		return fmt.Errorf("unknown unlock mode %d", mode)
+	}
+}
+
+// Deserialize parses the JoyID witness lock, and the alg is inferred from the length of the lock
+// because the secp256r1 lock is always longer than the secp256k1 lock
+func Deserialize(lock []byte) (JoyIDLock, error) {
+	if len(lock) == 0 {
+		return nil, errors.New("witness lock is empty")
+	}
+	if err := checkUnlockMode(UnlockMode(lock[0])); err != nil {
+		return nil, err
+	}
+	if len(lock) == Secp256k1LockLen {
+		return DeserializeSecp256k1Lock(lock)
+	}
+	return DeserializeSecp256r1Lock(lock)
+}
+
+// DeserializeWitnessArgs parses the JoyID lock from the lock field of WitnessArgs
+func DeserializeWitnessArgs(witness []byte) (JoyIDLock, error) {
+	witnessArgs, err := types.DeserializeWitnessArgs(witness)
+	if err != nil {
+		return nil, errors.New("witness must be WitnessArgs")
+	}
+	return Deserialize(witnessArgs.Lock)
+}
+
+func DeserializeSecp256k1Lock(lock []byte) (*Secp256k1Lock, error) {
+	if len(lock) != Secp256k1LockLen {
+		return nil, fmt.Errorf("secp256k1 witness lock must be %d bytes", Secp256k1LockLen)
+	}
+	if err := checkUnlockMode(UnlockMode(lock[0])); err != nil {
+		return nil, err
+	}
+	return &Secp256k1Lock{
+		Mode:       UnlockMode(lock[0]),
+		PubkeyHash: lock[1 : 1+pubkeyHashLen],
+		Signature:  lock[1+pubkeyHashLen:],
+	}, nil
+}
+
+func DeserializeSecp256r1Lock(lock []byte) (*Secp256r1Lock, error) {
+	if len(lock) < Secp256r1LockPrefixLen+AuthDataLen {
+		return nil, errors.New("secp256r1 witness lock is too short")
+	}
+	if err := checkUnlockMode(UnlockMode(lock[0])); err != nil {
+		return nil, err
+	}
+	authData := lock[Secp256r1LockPrefixLen : Secp256r1LockPrefixLen+AuthDataLen]
+	if authData[32]&authDataExtFlags != 0 {
+		return nil, errors.New("authData with attested credential data or extensions is not supported")
+	}
+	return &Secp256r1Lock{
+		Mode:       UnlockMode(lock[0]),
+		Pubkey:     lock[1 : 1+secp256r1PubkeyLen],
+		Signature:  lock[1+secp256r1PubkeyLen : Secp256r1LockPrefixLen],
+		AuthData:   authData,
+		ClientData: lock[Secp256r1LockPrefixLen+AuthDataLen:],
+	}, nil
+}
+
+func (l *Secp256k1Lock) UnlockMode() UnlockMode {
+	return l.Mode
+}
+
+func (l *Secp256k1Lock) Alg() alg.AlgIndex {
+	return alg.Secp256k1
+}
+
+func (l *Secp256k1Lock) Serialize() []byte {
+	lock := []byte{byte(l.Mode)}
+	lock = append(lock, l.PubkeyHash...)
+	return append(lock, l.Signature...)
+}
+
+func (l *Secp256r1Lock) UnlockMode() UnlockMode {
+	return l.Mode
+}
+
+func (l *Secp256r1Lock) Alg() alg.AlgIndex {
+	return alg.Secp256r1
+}
+
+func (l *Secp256r1Lock) Serialize() []byte {
+	lock := []byte{byte(l.Mode)}
+	lock = append(lock, l.Pubkey...)
+	lock = append(lock, l.Signature...)
+	lock = append(lock, l.AuthData...)
+	return append(lock, l.ClientData...)
+}
+
+type secp256k1LockJSON struct {
+	Alg        alg.AlgIndex  `json:"alg"`
+	Mode       UnlockMode    `json:"mode"`
+	PubkeyHash hexutil.Bytes `json:"pubkey_hash"`
+	Signature  hexutil.Bytes `json:"signature"`
+}
+
+type secp256r1LockJSON struct {
+	Alg        alg.AlgIndex  `json:"alg"`
+	Mode       UnlockMode    `json:"mode"`
+	Pubkey     hexutil.Bytes `json:"pubkey"`
+	Signature  hexutil.Bytes `json:"signature"`
+	AuthData   hexutil.Bytes `json:"auth_data"`
+	ClientData string        `json:"client_data"`
+}
+
+func (l *Secp256k1Lock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&secp256k1LockJSON{
+		Alg:        alg.Secp256k1,
+		Mode:       l.Mode,
+		PubkeyHash: l.PubkeyHash,
+		Signature:  l.Signature,
+	})
+}
+
+func (l *Secp256k1Lock) UnmarshalJSON(input []byte) error {
+	var jsonObj secp256k1LockJSON
+	if err := json.Unmarshal(input, &jsonObj); err != nil {
+		return err
+	}
+	*l = Secp256k1Lock{
+		Mode:       jsonObj.Mode,
+		PubkeyHash: jsonObj.PubkeyHash,
+		Signature:  jsonObj.Signature,
+	}
+	return nil
+}
+
+// MarshalJSON keeps clientData as the JSON string to be readable, and clientData must be valid UTF-8
+// to be decoded back byte for byte
+func (l *Secp256r1Lock) MarshalJSON() ([]byte, error) {
+	if !utf8.Valid(l.ClientData) {
+		return nil, errors.New("clientData must be valid UTF-8")
+	}
+	return json.Marshal(&secp256r1LockJSON{
+		Alg:        alg.Secp256r1,
+		Mode:       l.Mode,
+		Pubkey:     l.Pubkey,
+		Signature:  l.Signature,
+		AuthData:   l.AuthData,
+		ClientData: string(l.ClientData),
+	})
+}
+
+func (l *Secp256r1Lock) UnmarshalJSON(input []byte) error {
+	// encoding/json replaces invalid UTF-8 of the strings silently
+	if !utf8.Valid(input) {
+		return errors.New("JSON of secp256r1 witness lock must be valid UTF-8")
+	}
+	var jsonObj secp256r1LockJSON
+	if err := json.Unmarshal(input, &jsonObj); err != nil {
+		return err
+	}
+	*l = Secp256r1Lock{
+		Mode:       jsonObj.Mode,
+		Pubkey:     jsonObj.Pubkey,
+		Signature:  jsonObj.Signature,
+		AuthData:   jsonObj.AuthData,
+		ClientData: []byte(jsonObj.ClientData),
+	}
+	return nil
+}
+
+// UnmarshalJSON parses the JSON of Secp256k1Lock or Secp256r1Lock by the alg field
+func UnmarshalJSON(input []byte) (JoyIDLock, error) {
+	var jsonObj struct {
+		Alg alg.AlgIndex `json:"alg"`
+	}
+	if err := json.Unmarshal(input, &jsonObj); err != nil {
+		return nil, err
+	}
+	switch jsonObj.Alg {
+	case alg.Secp256k1:
+		lock := &Secp256k1Lock{}
+		return lock, json.Unmarshal(input, lock)
+	case alg.Secp256r1:
+		lock := &Secp256r1Lock{}
+		return lock, json.Unmarshal(input, lock)
+	default:
+		return nil, fmt.Errorf("unknown alg %d", jsonObj.Alg)
+	}
+}
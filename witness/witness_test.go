@@ -0,0 +1,121 @@
+package witness
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/nervina-labs/joyid-sdk-go/crypto/alg"
+)
+
+func filledBytes(length int, b byte) []byte {
+	return bytes.Repeat([]byte{b}, length)
+}
+
+func TestSecp256k1LockSerialize(t *testing.T) {
+	lock := &Secp256k1Lock{Mode: SubkeyUnlock, PubkeyHash: filledBytes(20, 0x11), Signature: filledBytes(65, 0x22)}
+	serialized := lock.Serialize()
+	if len(serialized) != Secp256k1LockLen {
+		t.Errorf("Serialize() length = %d, want %d", len(serialized), Secp256k1LockLen)
+	}
+	got, err := Deserialize(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("Deserialize() = %+v, want %+v", got, lock)
+	}
+}
+
+func TestSecp256r1LockSerialize(t *testing.T) {
+	authData := filledBytes(AuthDataLen, 0x33)
+	authData[32] = 0x05
+	lock := &Secp256r1Lock{
+		Mode:       NativeUnlock,
+		Pubkey:     filledBytes(64, 0x11),
+		Signature:  filledBytes(64, 0x22),
+		AuthData:   authData,
+		ClientData: []byte(`{"type":"webauthn.get"}`),
+	}
+	serialized := lock.Serialize()
+	if want := Secp256r1LockPrefixLen + AuthDataLen + len(lock.ClientData); len(serialized) != want {
+		t.Errorf("Serialize() length = %d, want %d", len(serialized), want)
+	}
+	got, err := Deserialize(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("Deserialize() = %+v, want %+v", got, lock)
+	}
+
+	authData[32] |= 0x40
+	if _, err := Deserialize(lock.Serialize()); err == nil {
+		t.Errorf("Deserialize() should fail with attested credential data")
+	}
+}
+
+func TestDeserializeInvalidLock(t *testing.T) {
+	for _, lock := range [][]byte{
+		{},
+		append([]byte{0x04}, filledBytes(Secp256k1LockLen-1, 0)...),
+		append([]byte{0x01}, filledBytes(Secp256r1LockPrefixLen, 0)...),
+	} {
+		if _, err := Deserialize(lock); err == nil {
+			t.Errorf("Deserialize(%x) should fail", lock)
+		}
+	}
+	if _, err := DeserializeSecp256k1Lock(filledBytes(Secp256k1LockLen+1, 1)); err == nil {
+		t.Errorf("DeserializeSecp256k1Lock() should fail with invalid length")
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	if got := len(Placeholder(alg.Secp256k1, 200)); got != Secp256k1LockLen {
+		t.Errorf("Placeholder() length = %d, want %d", got, Secp256k1LockLen)
+	}
+	if got, want := len(Placeholder(alg.Secp256r1, 200)), Secp256r1LockPrefixLen+200; got != want {
+		t.Errorf("Placeholder() length = %d, want %d", got, want)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	locks := []JoyIDLock{
+		&Secp256k1Lock{Mode: NativeUnlock, PubkeyHash: filledBytes(20, 0x11), Signature: filledBytes(65, 0x22)},
+		&Secp256r1Lock{
+			Mode:       SubkeyUnlock,
+			Pubkey:     filledBytes(64, 0x11),
+			Signature:  filledBytes(64, 0x22),
+			AuthData:   filledBytes(AuthDataLen, 0x05),
+			ClientData: []byte(`{"type":"webauthn.get"}`),
+		},
+	}
+	for _, lock := range locks {
+		data, err := json.Marshal(lock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnmarshalJSON(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, lock) {
+			t.Errorf("UnmarshalJSON() = %+v, want %+v", got, lock)
+		}
+	}
+
+	if _, err := UnmarshalJSON([]byte(`{"alg":3}`)); err == nil {
+		t.Errorf("UnmarshalJSON() should fail with unknown alg")
+	}
+
+	// clientData with invalid UTF-8 cannot be reproduced from JSON
+	invalidLock := &Secp256r1Lock{Mode: NativeUnlock, ClientData: []byte{'{', 0xff, '}'}}
+	if _, err := json.Marshal(invalidLock); err == nil {
+		t.Errorf("MarshalJSON() should fail with invalid UTF-8 clientData")
+	}
+	invalidJSON := append([]byte(`{"alg":1,"mode":1,"client_data":"`), 0xff, '"', '}')
+	if _, err := UnmarshalJSON(invalidJSON); err == nil {
+		t.Errorf("UnmarshalJSON() should fail with invalid UTF-8 clientData")
+	}
+}